@@ -0,0 +1,66 @@
+package cmd
+
+import (
+	"github.com/fhivemind/go-hastily/pkg/api"
+	. "github.com/fhivemind/go-hastily/pkg/global"
+	"github.com/spf13/cobra"
+)
+
+// applyFlags holds options of apply command.
+var applyFlags struct {
+	File   string
+	Set    []string
+	DryRun string
+	Yes    bool
+	Force  bool
+}
+
+var applyCmd = &cobra.Command{
+	Use:   "apply <model>",
+	Short: "Create objects from file or update them if they already exist",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		metas := loadManifests(applyFlags.File, applyFlags.Set)
+
+		handler := newAPI(args[0], applyFlags.DryRun)
+		models, err := handler.GetAll()
+		HandleError(err)
+
+		// split into new and existing objects
+		var updates []api.Meta
+		for i := range metas {
+			if metas[i].Model.ID != 0 && len(handler.ListFilter(models, &api.Filter{ID: metas[i].Model.ID})) > 0 {
+				updates = append(updates, metas[i])
+				continue
+			}
+			if err := handler.CreateMeta(&metas[i]); err != nil {
+				CLI.Error("Object #%d: %v", i+1, err)
+				continue
+			}
+			if handler.Client.DryRun != api.DryRunNone {
+				CLI.Info("Object #%d would be created (dry run).", i+1)
+				continue
+			}
+			CLI.Success("Object #%d created.", i+1)
+		}
+
+		// update existing
+		if len(updates) > 0 {
+			updated, statuses := updateFromMetas(handler, models, updates, true)
+			changed := changedModels(updated, statuses)
+			guardBulk(handler, "updating", changed, len(models), applyFlags.Force)
+			confirmBulk(handler, "updated", changed, applyFlags.Yes)
+			exportResponses(handler, updated, handler.UpdateMany(updated, statuses))
+		}
+	},
+}
+
+func init() {
+	applyCmd.Flags().StringVarP(&applyFlags.File, "file", "f", "", "YAML or JSON file with object definitions, - for stdin")
+	applyCmd.MarkFlagRequired("file")
+	addSetFlag(applyCmd, &applyFlags.Set)
+	addDryRunFlag(applyCmd, &applyFlags.DryRun)
+	addYesFlag(applyCmd, &applyFlags.Yes)
+	addForceFlag(applyCmd, &applyFlags.Force)
+	rootCmd.AddCommand(applyCmd)
+}
@@ -0,0 +1,140 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	cfg "github.com/fhivemind/go-hastily/config"
+	"github.com/fhivemind/go-hastily/pkg/api"
+	"github.com/fhivemind/go-hastily/pkg/auth"
+	"github.com/fhivemind/go-hastily/pkg/common"
+	. "github.com/fhivemind/go-hastily/pkg/global"
+	"github.com/fhivemind/go-hastily/pkg/transport"
+	"github.com/olekukonko/tablewriter"
+	"github.com/spf13/cobra"
+)
+
+// authStatusFlags holds options of auth status command.
+var authStatusFlags struct {
+	WarnBefore time.Duration
+}
+
+var authCmd = &cobra.Command{
+	Use:   "auth",
+	Short: "Inspect authentication",
+}
+
+var authStatusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "Show who is logged in and when the token expires",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		rows := [][]string{
+			{"Context", contextLabel(rootFlags.Context)},
+			{"Mode", auth.Mode()},
+		}
+		if auth.Mode() != auth.ModeLogin {
+			printRows(rows)
+			CLI.Info("Credentials for %s mode are read from the environment.", auth.Mode())
+			return
+		}
+
+		// load credentials
+		creds, err := auth.LoadCredentials()
+		if err != nil {
+			printRows(rows)
+			HandleErrorMessage(fmt.Sprintf("Not logged in: %v", err))
+		}
+		rows = append(rows, []string{"Username", PrintNonZero(creds.Username)})
+
+		// identity from id token, lifetime from access token
+		identity, identityErr := decodeFirst(creds.TokenId, creds.AccessToken)
+		access, accessErr := decodeFirst(creds.AccessToken, creds.TokenId)
+		if identityErr == nil {
+			rows = append(rows,
+				[]string{"Subject", identity.Subject},
+				[]string{"Email", identity.Email},
+				[]string{"Issuer", identity.Issuer},
+			)
+		}
+		var remaining time.Duration
+		if accessErr == nil {
+			rows = append(rows, []string{"Scopes", strings.Join(access.ScopeList(), " ")})
+			if expiry := access.Expiry(); !expiry.IsZero() {
+				remaining = time.Until(expiry)
+				rows = append(rows, []string{"Expires", fmt.Sprintf("%s (%s)", expiry.Format(time.RFC3339), formatRemaining(remaining))})
+			}
+		} else {
+			rows = append(rows, []string{"Token", "opaque, claims not available"})
+		}
+
+		// check connection
+		instance, err := transport.Client()
+		HandleError(err)
+		client := &api.Client{
+			Auth:          creds,
+			Authenticator: creds,
+			Instance:      instance,
+		}
+		if cfg.LoadConfig().VerifyEndpoint == "" {
+			rows = append(rows, []string{"Connection", "not checked, no verify endpoint configured"})
+		} else if resp := client.CheckConnection(); resp.Success {
+			rows = append(rows, []string{"Connection", "OK"})
+		} else {
+			rows = append(rows, []string{"Connection", resp.Message})
+		}
+		printRows(rows)
+
+		// warn about expiry
+		switch {
+		case accessErr != nil || access.Expiry().IsZero():
+		case remaining <= 0:
+			CLI.Error("Token has expired. Please login again.")
+			Exit(1)
+		case remaining < authStatusFlags.WarnBefore:
+			CLI.Warn("Token expires in %s. Please login again soon.", formatRemaining(remaining))
+		}
+	},
+}
+
+// decodeFirst decodes the first non-empty token.
+func decodeFirst(tokens ...string) (*auth.Claims, error) {
+	for _, token := range tokens {
+		if token != "" {
+			return auth.DecodeJWT(token)
+		}
+	}
+	return nil, fmt.Errorf("No token available.")
+}
+
+// formatRemaining prints remaining token lifetime.
+func formatRemaining(d time.Duration) string {
+	if d <= 0 {
+		return "expired " + (-d).Round(time.Second).String() + " ago"
+	}
+	return d.Round(time.Second).String() + " left"
+}
+
+// printRows prints key-value rows as a vertical table.
+func printRows(rows [][]string) {
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetAutoWrapText(false)
+	for i, row := range rows {
+		if i == 0 {
+			table.SetHeader(row)
+		} else {
+			table.Append(row)
+		}
+	}
+	ttype := common.Tabler.Vertical
+	ttype.SetStyleForTable(table, 2)
+	table.Render()
+}
+
+func init() {
+	authStatusCmd.Flags().DurationVar(&authStatusFlags.WarnBefore, "warn-before", 10*time.Minute, "Warn if token expires within this duration")
+	authCmd.AddCommand(authStatusCmd)
+	rootCmd.AddCommand(authCmd)
+}
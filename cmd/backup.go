@@ -0,0 +1,48 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	. "github.com/fhivemind/go-hastily/pkg/global"
+	"github.com/spf13/cobra"
+)
+
+// backupFlags holds options of backup command.
+var backupFlags struct {
+	Output   string
+	PageSize int
+}
+
+var backupCmd = &cobra.Command{
+	Use:   "backup <model>",
+	Short: "Save all objects of a model into tar.gz archive",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		handler := newAPI(args[0], "")
+		backup, err := handler.Backup(backupFlags.PageSize)
+		HandleError(err)
+
+		// write archive
+		output := backupFlags.Output
+		if output == "" {
+			output = fmt.Sprintf("%s-%s.tar.gz", args[0], time.Now().Format("20060102-150405"))
+		}
+		file, err := os.OpenFile(output, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+		HandleError(err)
+		if err = backup.Write(file); err != nil {
+			file.Close()
+			HandleError(err)
+		}
+		HandleError(file.Close())
+
+		CLI.Success("Saved %d %s into %s.", len(backup.Objects), args[0], output)
+	},
+}
+
+func init() {
+	backupCmd.Flags().StringVarP(&backupFlags.Output, "output", "o", "", "Archive file (default <model>-<timestamp>.tar.gz)")
+	backupCmd.Flags().IntVar(&backupFlags.PageSize, "page-size", 0, "Objects fetched per request (default pagination.page_size or 100)")
+	rootCmd.AddCommand(backupCmd)
+}
@@ -0,0 +1,28 @@
+package cmd
+
+import (
+	"github.com/fhivemind/go-hastily/pkg/api"
+	. "github.com/fhivemind/go-hastily/pkg/global"
+	"github.com/spf13/cobra"
+)
+
+var cacheCmd = &cobra.Command{
+	Use:   "cache",
+	Short: "Manage on-disk cache of backend responses",
+}
+
+var cacheClearCmd = &cobra.Command{
+	Use:   "clear",
+	Short: "Remove all cached responses",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		removed, err := api.ClearCache()
+		HandleError(err)
+		CLI.Success("Removed %d cached responses.", removed)
+	},
+}
+
+func init() {
+	cacheCmd.AddCommand(cacheClearCmd)
+	rootCmd.AddCommand(cacheCmd)
+}
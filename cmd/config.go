@@ -0,0 +1,169 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	cfg "github.com/fhivemind/go-hastily/config"
+	"github.com/fhivemind/go-hastily/pkg/common"
+	. "github.com/fhivemind/go-hastily/pkg/global"
+	"github.com/olekukonko/tablewriter"
+	"github.com/spf13/cobra"
+)
+
+// configFlags holds options of config subcommands.
+var configFlags struct {
+	File string
+}
+
+var configCmd = &cobra.Command{
+	Use:   "config",
+	Short: "Inspect and manage configuration",
+}
+
+var configViewCmd = &cobra.Command{
+	Use:   "view",
+	Short: "Show merged configuration and the source of each value",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		CLI.Subtitle("Current context: %s", contextLabel(rootFlags.Context))
+
+		table := tablewriter.NewWriter(os.Stdout)
+		table.SetHeader([]string{"Key", "Value", "Source"})
+		ttype := common.Tabler.Basic
+		ttype.SetStyleForTable(table, 3)
+		table.SetAutoWrapText(false)
+		for _, setting := range cfg.Settings() {
+			table.Append([]string{setting.Key, fmt.Sprintf("%v", setting.Value), setting.Source})
+		}
+		table.Render()
+	},
+}
+
+var configGetCmd = &cobra.Command{
+	Use:   "get <key>",
+	Short: "Print merged value of a config key",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		for _, setting := range cfg.Settings() {
+			if setting.Key == args[0] {
+				CLI.Info("%v", setting.Value)
+				return
+			}
+		}
+		HandleErrorMessage(fmt.Sprintf("Key %s is not set.", args[0]))
+	},
+}
+
+var configSetCmd = &cobra.Command{
+	Use:   "set <key> <value>",
+	Short: "Set a key in the config file",
+	Long: `Set a key in the config file. The value is validated against the config
+schema, and lists are given as comma-separated values.`,
+	Args: cobra.ExactArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		file := openConfigFile()
+		HandleError(file.Set(args[0], args[1]))
+		HandleError(file.Save())
+		CLI.Success("Set %s in %s.", args[0], file.Path)
+	},
+}
+
+var configUnsetCmd = &cobra.Command{
+	Use:   "unset <key>",
+	Short: "Remove a key from the config file",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		file := openConfigFile()
+		if !file.Unset(args[0]) {
+			CLI.Warn("Key %s is not set in %s.", args[0], file.Path)
+			return
+		}
+		HandleError(file.Save())
+		CLI.Success("Removed %s from %s.", args[0], file.Path)
+	},
+}
+
+var configValidateCmd = &cobra.Command{
+	Use:   "validate [file...]",
+	Short: "Validate config files against the schema",
+	Long: `Validate config files against the schema. Without arguments, all config
+files which are currently loaded are validated.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		files := args
+		if len(files) == 0 {
+			files = cfg.ConfigFiles(cfg.Options{File: rootFlags.ConfigFile})
+		}
+		if len(files) == 0 {
+			CLI.Warn("No config files found.")
+			return
+		}
+
+		invalid := 0
+		for _, path := range files {
+			file, err := cfg.OpenFile(path)
+			HandleError(err)
+			if file.Version() < cfg.CurrentVersion {
+				CLI.Warn("%s uses config version %d, run 'config migrate --file %s' to upgrade.", path, file.Version(), path)
+			}
+
+			// validate interpolated and migrated values
+			loaded, err := cfg.LoadFile(path)
+			HandleError(err)
+			values, err := loaded.Values()
+			HandleError(err)
+
+			errs := cfg.ValidateValues(values)
+			if len(errs) == 0 {
+				CLI.Success("%s is valid.", path)
+				continue
+			}
+			invalid++
+			CLI.Error("%s is invalid:", path)
+			for _, err := range errs {
+				CLI.Info("  - %v", err)
+			}
+		}
+		if invalid > 0 {
+			Exit(1)
+		}
+	},
+}
+
+var configMigrateCmd = &cobra.Command{
+	Use:   "migrate",
+	Short: "Upgrade config file to the current layout",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		file := openConfigFile()
+		from, err := file.Migrate()
+		HandleError(err)
+		if from == cfg.CurrentVersion {
+			CLI.Info("%s already uses config version %d.", file.Path, from)
+			return
+		}
+		HandleError(file.Save())
+		CLI.Success("Migrated %s from version %d to %d.", file.Path, from, cfg.CurrentVersion)
+	},
+}
+
+// openConfigFile opens file selected with --file, or the user config file.
+func openConfigFile() *cfg.File {
+	path := configFlags.File
+	if path == "" {
+		userFile, err := cfg.UserConfigFile()
+		HandleError(err)
+		path = userFile
+	}
+	file, err := cfg.OpenFile(path)
+	HandleError(err)
+	return file
+}
+
+func init() {
+	for _, cmd := range []*cobra.Command{configSetCmd, configUnsetCmd, configMigrateCmd} {
+		cmd.Flags().StringVar(&configFlags.File, "file", "", "Config file to edit (defaults to user config file)")
+	}
+	configCmd.AddCommand(configViewCmd, configGetCmd, configSetCmd, configUnsetCmd, configValidateCmd, configMigrateCmd)
+	rootCmd.AddCommand(configCmd)
+}
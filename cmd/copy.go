@@ -0,0 +1,204 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+
+	cfg "github.com/fhivemind/go-hastily/config"
+	"github.com/fhivemind/go-hastily/pkg/api"
+	. "github.com/fhivemind/go-hastily/pkg/global"
+	"github.com/spf13/cobra"
+)
+
+// copyFlags holds options of copy command.
+var copyFlags struct {
+	From    string
+	To      string
+	Where   []string
+	Strip   []string
+	Mapping string
+	DryRun  string
+	Yes     bool
+	Force   bool
+}
+
+var copyCmd = &cobra.Command{
+	Use:   "copy <model>",
+	Short: "Copy objects of a model from one context to another",
+	Long: `Copy objects of a model from one context to another, e.g. to promote
+them from staging to prod.
+
+Objects are read in the source context and selected with --where. Server
+managed fields are stripped and fields are transformed with an optional
+mapping file before objects are compared with the target context. Missing
+objects are created and changed ones updated, after a diff preview.
+
+Mapping file example:
+
+  delete: [internal_notes]
+  rename: {stage_url: url}
+  replace: {url: {staging.example.com: example.com}}
+  set: {environment: prod}`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		from, to := resolveContext(copyFlags.From), resolveContext(copyFlags.To)
+		if from == to {
+			HandleErrorMessage("Source and target contexts must differ.")
+		}
+		where, err := api.ParseWhere(copyFlags.Where)
+		HandleError(err)
+		var mapping *api.Mapping
+		if copyFlags.Mapping != "" {
+			mapping, err = api.LoadMapping(copyFlags.Mapping)
+			HandleError(err)
+		}
+
+		// read and transform in source context
+		HandleError(cfg.UseContext(from))
+		source := newAPI(args[0], "")
+		objects, err := source.GetPages(0)
+		HandleError(err)
+		strip := stripFields()
+		desired, err := selectObjects(objects, where, strip, mapping)
+		HandleError(err)
+		CLI.Info("Selected %d/%d %s in %s.", len(desired), len(objects), args[0], contextLabel(from))
+
+		// compare in target context
+		HandleError(cfg.UseContext(to))
+		target := newAPI(args[0], copyFlags.DryRun)
+		existingObjects, err := target.GetPages(0)
+		HandleError(err)
+		existing, err := objectsByID(existingObjects, strip)
+		HandleError(err)
+
+		// plan and preview
+		var creates, updates []api.Meta
+		unchanged := 0
+		for _, object := range desired {
+			meta, err := api.NewMeta(object)
+			HandleError(err)
+
+			id := meta.Model.ID
+			current, ok := existing[id]
+			if id == 0 || !ok {
+				CLI.Info("+ %s/%s", args[0], objectLabel(id))
+				creates = append(creates, meta)
+				continue
+			}
+			lines, err := api.DiffObjects(current, object)
+			HandleError(err)
+			if len(lines) == 0 {
+				unchanged++
+				continue
+			}
+			CLI.Info("~ %s/%d", args[0], id)
+			for _, line := range lines {
+				CLI.Info("    %s", line)
+			}
+			updates = append(updates, meta)
+		}
+		CLI.Subtitle("%d to create, %d to update, %d unchanged in %s", len(creates), len(updates), unchanged, contextLabel(to))
+		if len(creates)+len(updates) == 0 {
+			return
+		}
+		copied := metaModels(append(append([]api.Meta(nil), creates...), updates...))
+		guardBulk(target, "copying", copied, len(existing), copyFlags.Force)
+		confirmBulk(target, fmt.Sprintf("copied to %s", contextLabel(to)), copied, copyFlags.Yes)
+
+		// write full objects and report
+		report := target.UpdateMetas(updates)
+		for i := range creates {
+			resp := target.Client.DefaultResponse("created", target.CreateMeta(&creates[i]))
+			key := objectLabel(creates[i].Model.ID)
+			if creates[i].Model.ID == 0 {
+				key = "#" + strconv.Itoa(i+1)
+			}
+			report.Insert(key, &resp)
+		}
+		exportResponses(target, copied, report)
+	},
+}
+
+// resolveContext maps "default" to no context unless such context is defined.
+func resolveContext(name string) string {
+	if name != "default" {
+		return name
+	}
+	for _, defined := range cfg.ContextNames() {
+		if defined == name {
+			return name
+		}
+	}
+	return ""
+}
+
+// stripFields returns server-managed fields removed from copied objects.
+func stripFields() []string {
+	fields := cfg.LoadConfig().Copy.StripFields
+	if len(fields) == 0 {
+		fields = api.DefaultStripFields
+	}
+	return append(append([]string(nil), fields...), copyFlags.Strip...)
+}
+
+// selectObjects filters and transforms raw objects.
+func selectObjects(objects []json.RawMessage, where api.Where, strip []string, mapping *api.Mapping) ([]map[string]interface{}, error) {
+	var selected []map[string]interface{}
+	for _, raw := range objects {
+		var object map[string]interface{}
+		if err := json.Unmarshal(raw, &object); err != nil {
+			return nil, err
+		}
+		if !where.Match(object) {
+			continue
+		}
+		api.StripFields(object, strip)
+		if mapping != nil {
+			mapping.Apply(object)
+		}
+		selected = append(selected, object)
+	}
+	return selected, nil
+}
+
+// objectsByID decodes raw objects into a map by their ID, without
+// server-managed fields so that they can be compared.
+func objectsByID(objects []json.RawMessage, strip []string) (map[int]map[string]interface{}, error) {
+	ret := make(map[int]map[string]interface{}, len(objects))
+	for _, raw := range objects {
+		var object map[string]interface{}
+		if err := json.Unmarshal(raw, &object); err != nil {
+			return nil, err
+		}
+		var model api.Model
+		if err := json.Unmarshal(raw, &model); err != nil {
+			return nil, err
+		}
+		api.StripFields(object, strip)
+		ret[model.ID] = object
+	}
+	return ret, nil
+}
+
+// objectLabel formats object ID, or "new" for objects without one.
+func objectLabel(id int) string {
+	if id == 0 {
+		return "new"
+	}
+	return strconv.Itoa(id)
+}
+
+func init() {
+	copyCmd.Flags().StringVar(&copyFlags.From, "from", "", "Source context")
+	copyCmd.Flags().StringVar(&copyFlags.To, "to", "", "Target context")
+	copyCmd.Flags().StringArrayVar(&copyFlags.Where, "where", nil, "Only copy objects matching field=value or field!=value (repeatable)")
+	copyCmd.Flags().StringArrayVar(&copyFlags.Strip, "strip", nil, "Additional field to remove before copying, e.g. --strip id (repeatable)")
+	copyCmd.Flags().StringVar(&copyFlags.Mapping, "mapping", "", "YAML or JSON file with field transformations")
+	copyCmd.MarkFlagRequired("from")
+	copyCmd.MarkFlagRequired("to")
+	addDryRunFlag(copyCmd, &copyFlags.DryRun)
+	addYesFlag(copyCmd, &copyFlags.Yes)
+	addForceFlag(copyCmd, &copyFlags.Force)
+	rootCmd.AddCommand(copyCmd)
+}
@@ -0,0 +1,81 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+
+	"github.com/fhivemind/go-hastily/pkg/api"
+	"github.com/fhivemind/go-hastily/pkg/common"
+	. "github.com/fhivemind/go-hastily/pkg/global"
+	"github.com/spf13/cobra"
+)
+
+// createFlags holds options of create command.
+var createFlags struct {
+	File   string
+	Set    []string
+	DryRun string
+}
+
+var createCmd = &cobra.Command{
+	Use:   "create <model>",
+	Short: "Create objects on backend from file or interactively",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		var metas []api.Meta
+		if createFlags.File != "" {
+			metas = loadManifests(createFlags.File, createFlags.Set)
+		} else {
+			meta, err := promptMeta()
+			HandleError(err)
+			metas = append(metas, *meta)
+			HandleError(api.ApplyOverrides(metas, createFlags.Set))
+		}
+
+		handler := newAPI(args[0], createFlags.DryRun)
+		dryRun := handler.Client.DryRun != api.DryRunNone
+		created, planned := 0, 0
+		for i := range metas {
+			if err := handler.CreateMeta(&metas[i]); err != nil {
+				CLI.Error("Object #%d: %v", i+1, err)
+				continue
+			}
+			if dryRun {
+				planned++
+				continue
+			}
+			created++
+		}
+		if dryRun {
+			CLI.Info("%d/%d objects would be created (dry run).", planned, len(metas))
+			return
+		}
+		CLI.Success("%d/%d objects created.", created, len(metas))
+	},
+}
+
+// promptMeta asks user for values of each model field.
+func promptMeta() (*api.Meta, error) {
+	if !common.IsInteractive() {
+		return nil, errors.New("Unable to prompt for object values, stdin is not a terminal. Pass a manifest with --file.")
+	}
+	var model api.Model
+	if err := common.PromptStruct(&model); err != nil {
+		return nil, err
+	}
+	byt, err := json.Marshal(model)
+	if err != nil {
+		return nil, err
+	}
+	return &api.Meta{
+		Model: model,
+		Data:  byt,
+	}, nil
+}
+
+func init() {
+	createCmd.Flags().StringVarP(&createFlags.File, "file", "f", "", "YAML or JSON file with object definitions, - for stdin (prompts for values if omitted)")
+	addSetFlag(createCmd, &createFlags.Set)
+	addDryRunFlag(createCmd, &createFlags.DryRun)
+	rootCmd.AddCommand(createCmd)
+}
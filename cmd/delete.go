@@ -0,0 +1,39 @@
+package cmd
+
+import (
+	"github.com/fhivemind/go-hastily/pkg/api"
+	. "github.com/fhivemind/go-hastily/pkg/global"
+	"github.com/spf13/cobra"
+)
+
+// deleteFlags holds options of delete command.
+var deleteFlags struct {
+	ID     int
+	DryRun string
+	Yes    bool
+	Force  bool
+}
+
+var deleteCmd = &cobra.Command{
+	Use:   "delete <model>",
+	Short: "Delete objects matching a filter",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		handler := newAPI(args[0], deleteFlags.DryRun)
+		all, err := handler.GetAll()
+		HandleError(err)
+		models := handler.ListFilter(all, &api.Filter{ID: deleteFlags.ID})
+
+		guardBulk(handler, "deleting", models, len(all), deleteFlags.Force)
+		confirmBulk(handler, "deleted", models, deleteFlags.Yes)
+		exportResponses(handler, models, handler.DeleteMany(models))
+	},
+}
+
+func init() {
+	deleteCmd.Flags().IntVar(&deleteFlags.ID, "id", 0, "Only delete object with this ID")
+	addDryRunFlag(deleteCmd, &deleteFlags.DryRun)
+	addYesFlag(deleteCmd, &deleteFlags.Yes)
+	addForceFlag(deleteCmd, &deleteFlags.Force)
+	rootCmd.AddCommand(deleteCmd)
+}
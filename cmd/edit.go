@@ -0,0 +1,160 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/fhivemind/go-hastily/pkg/api"
+	"github.com/fhivemind/go-hastily/pkg/common"
+	. "github.com/fhivemind/go-hastily/pkg/global"
+	"github.com/ghodss/yaml"
+	"github.com/spf13/cobra"
+)
+
+// editHeader is prepended to the file opened in editor.
+const editHeader = `# Please edit the object below. Lines beginning with '#' are ignored,
+# and an empty file will abort the edit.
+`
+
+// editFlags holds options of edit command.
+var editFlags struct {
+	DryRun string
+}
+
+var editCmd = &cobra.Command{
+	Use:   "edit <model> <id>",
+	Short: "Edit an object in $EDITOR and update it on backend",
+	Args:  cobra.ExactArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		id, err := strconv.Atoi(args[1])
+		HandleError(err)
+
+		// fetch object
+		handler := newAPI(args[0], editFlags.DryRun)
+		original, err := handler.GetMeta(id)
+		HandleError(err)
+		if original == nil {
+			HandleErrorMessage(fmt.Sprintf("Object %s/%d not found.", args[0], id))
+		}
+
+		// edit until valid
+		meta, err := editMeta(original)
+		HandleError(err)
+		if meta == nil {
+			CLI.Warn("Edit cancelled, no changes made.")
+			return
+		}
+
+		// update only if changed
+		if sameJSON(original.Data, meta.Data) {
+			CLI.Warn("Edit cancelled, no change.")
+			return
+		}
+		resp := handler.UpdateMeta(meta)
+		if !resp.Success {
+			HandleErrorMessage(resp.Message)
+		}
+		CLI.Success("Object %s/%d updated.", args[0], id)
+	},
+}
+
+// editMeta opens object as yaml in editor and returns the edited object.
+// Returns nil if user aborted the edit.
+func editMeta(meta *api.Meta) (*api.Meta, error) {
+
+	// serialize object
+	original, err := yaml.JSONToYAML(meta.Data)
+	if err != nil {
+		return nil, err
+	}
+
+	// create temp file
+	file, err := ioutil.TempFile("", "go-hastily-edit-*.yaml")
+	if err != nil {
+		return nil, err
+	}
+	defer os.Remove(file.Name())
+	file.Close()
+
+	content := original
+	var editErr error
+	for {
+		// write content with error inlined
+		header := editHeader
+		if editErr != nil {
+			header += fmt.Sprintf("#\n# The edited object is invalid: %s\n", strings.Replace(editErr.Error(), "\n", "\n# ", -1))
+		}
+		if err := ioutil.WriteFile(file.Name(), append([]byte(header+"\n"), content...), 0600); err != nil {
+			return nil, err
+		}
+
+		// edit
+		if err := common.EditFile(file.Name()); err != nil {
+			return nil, err
+		}
+		edited, err := ioutil.ReadFile(file.Name())
+		if err != nil {
+			return nil, err
+		}
+		content = stripComments(edited)
+
+		// aborted
+		if len(bytes.TrimSpace(content)) == 0 {
+			return nil, nil
+		}
+
+		// validate
+		result, err := validateEdit(meta, content)
+		if err == nil {
+			return result, nil
+		}
+		editErr = err
+	}
+}
+
+// validateEdit checks that edited content is a single object with unchanged ID.
+func validateEdit(original *api.Meta, content []byte) (*api.Meta, error) {
+	metas, err := api.ParseMetas(content)
+	if err != nil {
+		return nil, err
+	}
+	if len(metas) != 1 {
+		return nil, fmt.Errorf("expected a single object, found %d", len(metas))
+	}
+	if metas[0].Model.ID != original.Model.ID {
+		return nil, errors.New("the object ID cannot be changed")
+	}
+	return &metas[0], nil
+}
+
+// sameJSON checks if two json documents hold the same values.
+func sameJSON(a, b []byte) bool {
+	var valueA, valueB interface{}
+	if json.Unmarshal(a, &valueA) != nil || json.Unmarshal(b, &valueB) != nil {
+		return false
+	}
+	return reflect.DeepEqual(valueA, valueB)
+}
+
+// stripComments removes full-line comments from yaml content.
+func stripComments(content []byte) []byte {
+	var out bytes.Buffer
+	for _, line := range strings.SplitAfter(string(content), "\n") {
+		if !strings.HasPrefix(strings.TrimSpace(line), "#") {
+			out.WriteString(line)
+		}
+	}
+	return out.Bytes()
+}
+
+func init() {
+	addDryRunFlag(editCmd, &editFlags.DryRun)
+	rootCmd.AddCommand(editCmd)
+}
@@ -0,0 +1,57 @@
+package cmd
+
+import (
+	"github.com/fhivemind/go-hastily/pkg/api"
+	"github.com/fhivemind/go-hastily/pkg/common"
+	. "github.com/fhivemind/go-hastily/pkg/global"
+	"github.com/spf13/cobra"
+)
+
+// getFlags holds options of get command.
+var getFlags struct {
+	ID      int
+	Output  string
+	File    string
+	Sort    string
+	Offline bool
+}
+
+var getCmd = &cobra.Command{
+	Use:   "get <model>",
+	Short: "Fetch objects of a model from backend",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		ttype, err := common.ParseTableType(getFlags.Output)
+		HandleError(err)
+
+		// fetch from backend or local snapshot
+		var (
+			handler *api.ApiModel
+			models  []*api.Model
+		)
+		modelFilter := &api.Filter{ID: getFlags.ID}
+		if getFlags.Offline {
+			handler = &api.ApiModel{Name: args[0]}
+			models, err = handler.GetOffline(modelFilter, getFlags.Sort)
+		} else {
+			handler = newAPI(args[0], "")
+			models, err = handler.GetSorted(modelFilter, getFlags.Sort)
+		}
+		HandleError(err)
+
+		HandleError(handler.Export(api.ExportModel{
+			Data:       models,
+			Type:       ttype,
+			OutputFile: getFlags.File,
+		}))
+	},
+}
+
+func init() {
+	getCmd.Flags().IntVar(&getFlags.ID, "id", 0, "Only fetch object with this ID")
+	getCmd.Flags().StringVarP(&getFlags.Output, "output", "o", "basic", "Output format (basic, preview, markdown, csv)")
+	getCmd.Flags().StringVar(&getFlags.File, "output-file", "", "Write output to file instead of stdout")
+	getCmd.Flags().StringVar(&getFlags.Sort, "sort", "", "Sort by field of full objects, prefix with - for descending order, e.g. -name")
+	getCmd.Flags().BoolVar(&getFlags.Offline, "offline", false, "Query local snapshot made by sync instead of backend")
+	rootCmd.AddCommand(getCmd)
+}
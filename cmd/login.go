@@ -0,0 +1,87 @@
+package cmd
+
+import (
+	"errors"
+
+	"github.com/fhivemind/go-hastily/pkg/auth"
+	"github.com/fhivemind/go-hastily/pkg/common"
+	. "github.com/fhivemind/go-hastily/pkg/global"
+	"github.com/spf13/cobra"
+)
+
+// loginFlags holds options of login command.
+var loginFlags struct {
+	Username string
+	Device   bool
+	Browser  bool
+}
+
+var loginCmd = &cobra.Command{
+	Use:   "login",
+	Short: "Log in to backend and save credentials",
+	Long: `Log in to backend and save credentials.
+
+By default username and password are prompted for and exchanged using the
+OAuth password grant. Use --device for the device authorization flow, or
+--browser for the authorization code flow with PKCE.`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		var (
+			creds *auth.Credentials
+			err   error
+		)
+		switch {
+		case loginFlags.Device && loginFlags.Browser:
+			err = errors.New("Use only one of --device and --browser.")
+		case loginFlags.Device:
+			creds, err = auth.GetDeviceCredentials(func(code *auth.DeviceCode) {
+				CLI.Subtitle("Visit %s and enter code %s", code.VerificationURI, code.UserCode)
+				if code.VerificationURIComplete != "" {
+					CLI.Desc("Or open %s directly.", code.VerificationURIComplete)
+				}
+				CLI.Info("Waiting for approval...")
+			})
+		case loginFlags.Browser:
+			creds, err = auth.GetBrowserCredentials(func(url string) {
+				CLI.Subtitle("Opening browser to log in...")
+				if common.OpenBrowser(url) != nil {
+					CLI.Warn("Unable to open browser.")
+				}
+				CLI.Desc("If the browser did not open, visit %s", url)
+			})
+		default:
+			creds, err = passwordLogin()
+		}
+		HandleError(err)
+
+		HandleError(creds.Save())
+		if creds.Username != "" {
+			CLI.Success("Logged in as %s.", creds.Username)
+		} else {
+			CLI.Success("Logged in.")
+		}
+	},
+}
+
+// passwordLogin prompts for username and password and exchanges them for token.
+func passwordLogin() (*auth.Credentials, error) {
+	if !common.IsInteractive() {
+		return nil, errors.New("Unable to prompt for username and password, stdin is not a terminal.")
+	}
+	username, err := common.PromptText("Username", loginFlags.Username)
+	if err != nil {
+		return nil, err
+	}
+	password, err := common.PromptPassword("Password")
+	if err != nil {
+		return nil, err
+	}
+	return auth.GetCredentials(username, password)
+}
+
+func init() {
+	loginCmd.Flags().StringVarP(&loginFlags.Username, "username", "u", "", "Default username shown in prompt")
+	loginCmd.Flags().BoolVar(&loginFlags.Device, "device", false, "Log in using the OAuth device authorization flow")
+	loginCmd.Flags().BoolVar(&loginFlags.Browser, "browser", false, "Log in using the browser with OAuth authorization code and PKCE")
+	rootCmd.AddCommand(loginCmd)
+}
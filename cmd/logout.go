@@ -0,0 +1,69 @@
+package cmd
+
+import (
+	"errors"
+
+	cfg "github.com/fhivemind/go-hastily/config"
+	"github.com/fhivemind/go-hastily/pkg/auth"
+	. "github.com/fhivemind/go-hastily/pkg/global"
+	"github.com/spf13/cobra"
+)
+
+// logoutFlags holds options of logout command.
+var logoutFlags struct {
+	All bool
+}
+
+var logoutCmd = &cobra.Command{
+	Use:   "logout",
+	Short: "Revoke and remove saved credentials",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		contexts := []string{rootFlags.Context}
+		if logoutFlags.All {
+			contexts = append([]string{""}, cfg.ContextNames()...)
+		}
+
+		failed := false
+		for _, name := range contexts {
+			if logoutFlags.All {
+				HandleError(cfg.UseContext(name))
+			}
+			label := contextLabel(name)
+
+			found, err := auth.Logout()
+			var revokeErr *auth.RevokeError
+			switch {
+			case errors.As(err, &revokeErr):
+				CLI.Warn("Context %s: %v", label, err)
+				CLI.Success("Logged out of context %s.", label)
+			case err != nil:
+				CLI.Error("Context %s: %v", label, err)
+				failed = true
+			case found:
+				CLI.Success("Logged out of context %s.", label)
+			case !logoutFlags.All:
+				CLI.Warn("Not logged in to context %s.", label)
+			}
+		}
+		if failed {
+			HandleErrorMessage("Some credentials could not be removed.")
+		}
+	},
+}
+
+// contextLabel returns printable context name.
+func contextLabel(name string) string {
+	if name == "" {
+		name = cfg.LoadConfig().Context
+	}
+	if name == "" {
+		return "default"
+	}
+	return name
+}
+
+func init() {
+	logoutCmd.Flags().BoolVar(&logoutFlags.All, "all", false, "Log out of every context")
+	rootCmd.AddCommand(logoutCmd)
+}
@@ -0,0 +1,101 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	cfg "github.com/fhivemind/go-hastily/config"
+	"github.com/fhivemind/go-hastily/pkg/api"
+	. "github.com/fhivemind/go-hastily/pkg/global"
+	"github.com/fhivemind/go-hastily/pkg/mock"
+	"github.com/spf13/cobra"
+)
+
+// mockFlags holds options of mock command.
+var mockFlags struct {
+	Addr      string
+	Seeds     []string
+	Latency   time.Duration
+	ErrorRate float64
+}
+
+var mockCmd = &cobra.Command{
+	Use:   "mock",
+	Short: "Start a local in-memory backend for any model",
+	Long: `Start a local in-memory backend for any model.
+
+Every path is served as a collection named by its last segment, e.g.
+GET/POST /api/users/ and GET/PUT/PATCH/DELETE /api/users/1. Lists support
+page and per_page pagination and filtering by field values, e.g. ?name=bob.
+Login and verify endpoints of the current context are served as well, so
+point api, login and verify at the mock address to use it.`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		if mockFlags.ErrorRate < 0 || mockFlags.ErrorRate > 1 {
+			HandleErrorMessage("Error rate must be between 0 and 1.")
+		}
+
+		envCfg := cfg.LoadConfig()
+		server := mock.NewServer(mock.Options{
+			LoginPath:    urlPath(envCfg.LoginEndpoint),
+			VerifyPath:   urlPath(envCfg.VerifyEndpoint),
+			DryRunParam:  envCfg.DryRunParam,
+			DryRunHeader: envCfg.DryRunHeader,
+			Latency:      mockFlags.Latency,
+			ErrorRate:    mockFlags.ErrorRate,
+		})
+
+		// seed collections
+		for _, seed := range mockFlags.Seeds {
+			parts := strings.SplitN(seed, "=", 2)
+			if len(parts) != 2 || parts[0] == "" {
+				HandleErrorMessage(fmt.Sprintf("Invalid seed %q, expected model=file.", seed))
+			}
+			objects, err := loadObjects(parts[1])
+			HandleError(err)
+			server.Seed(parts[0], objects)
+			CLI.Info("Seeded %d %s from %s.", len(objects), parts[0], parts[1])
+		}
+
+		CLI.Success("Mock backend listening on http://%s", mockFlags.Addr)
+		HandleError(http.ListenAndServe(mockFlags.Addr, server))
+	},
+}
+
+// loadObjects reads objects from YAML or JSON file.
+func loadObjects(file string) ([]mock.Object, error) {
+	metas, err := api.LoadMetas(file)
+	if err != nil {
+		return nil, err
+	}
+	objects := make([]mock.Object, 0, len(metas))
+	for _, meta := range metas {
+		var object mock.Object
+		if err := json.Unmarshal(meta.Data, &object); err != nil {
+			return nil, err
+		}
+		objects = append(objects, object)
+	}
+	return objects, nil
+}
+
+// urlPath returns path of endpoint URL, or empty string if it is not set.
+func urlPath(endpoint string) string {
+	u, err := url.Parse(endpoint)
+	if err != nil || endpoint == "" {
+		return ""
+	}
+	return u.Path
+}
+
+func init() {
+	mockCmd.Flags().StringVar(&mockFlags.Addr, "addr", "127.0.0.1:8080", "Address to listen on")
+	mockCmd.Flags().StringArrayVar(&mockFlags.Seeds, "seed", nil, "Seed model from YAML or JSON file, e.g. --seed users=users.yaml (repeatable)")
+	mockCmd.Flags().DurationVar(&mockFlags.Latency, "latency", 0, "Latency added to every response, e.g. 200ms")
+	mockCmd.Flags().Float64Var(&mockFlags.ErrorRate, "error-rate", 0, "Share of requests failing with 500, from 0 to 1")
+	rootCmd.AddCommand(mockCmd)
+}
@@ -0,0 +1,191 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+
+	cfg "github.com/fhivemind/go-hastily/config"
+	"github.com/fhivemind/go-hastily/pkg/api"
+	. "github.com/fhivemind/go-hastily/pkg/global"
+	"github.com/spf13/cobra"
+)
+
+// Conflict policies for objects which already exist on backend.
+const (
+	conflictSkip      = "skip"
+	conflictOverwrite = "overwrite"
+	conflictFail      = "fail"
+)
+
+// restoreFlags holds options of restore command.
+var restoreFlags struct {
+	Model      string
+	OnConflict string
+	MapIDs     []string
+	DryRun     string
+	Yes        bool
+	Force      bool
+}
+
+var restoreCmd = &cobra.Command{
+	Use:   "restore <archive>",
+	Short: "Restore objects from backup archive",
+	Long: `Restore objects from backup archive.
+
+Objects missing on backend are created. Objects which already exist and
+differ from the backup are handled by the conflict policy: skip leaves
+them as they are, overwrite updates them and fail aborts before any change.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		switch restoreFlags.OnConflict {
+		case conflictSkip, conflictOverwrite, conflictFail:
+		default:
+			HandleErrorMessage(fmt.Sprintf("Invalid conflict policy %q. Allowed values are skip, overwrite or fail.", restoreFlags.OnConflict))
+		}
+
+		// read archive
+		file, err := os.Open(args[0])
+		HandleError(err)
+		backup, err := api.ReadBackup(file)
+		file.Close()
+		HandleError(err)
+		model := backup.Metadata.Model
+		if restoreFlags.Model != "" {
+			model = restoreFlags.Model
+		}
+		if from, to := backup.Metadata.Context, cfg.LoadConfig().Context; from != to {
+			if from == "" {
+				from = "default"
+			}
+			CLI.Warn("Backup was made in context %s, restoring into %s.", from, contextLabel(to))
+		}
+
+		// remap ids
+		metas, err := backup.Metas()
+		HandleError(err)
+		HandleError(remapIDs(metas, restoreFlags.MapIDs))
+
+		// split into missing and changed objects
+		handler := newAPI(model, restoreFlags.DryRun)
+		objects, err := handler.GetPages(0)
+		HandleError(err)
+		current, err := backendObjects(objects)
+		HandleError(err)
+		var missing, changed []api.Meta
+		unchanged := 0
+		for i := range metas {
+			object, ok := current[metas[i].Model.ID]
+			if metas[i].Model.ID == 0 || !ok {
+				missing = append(missing, metas[i])
+				continue
+			}
+			var desired map[string]interface{}
+			HandleError(json.Unmarshal(metas[i].Data, &desired))
+			if reflect.DeepEqual(object, desired) {
+				unchanged++
+				continue
+			}
+			changed = append(changed, metas[i])
+		}
+
+		// resolve conflicts before making any change
+		if len(changed) > 0 {
+			switch restoreFlags.OnConflict {
+			case conflictFail:
+				HandleErrorMessage(fmt.Sprintf("%d objects differ from backup: %s. Use --on-conflict skip or overwrite.", len(changed), modelIDs(metaModels(changed))))
+			case conflictSkip:
+				CLI.Warn("Skipping %d objects which differ from backup: %s.", len(changed), modelIDs(metaModels(changed)))
+				changed = nil
+			}
+		}
+		CLI.Subtitle("%d to create, %d to overwrite, %d unchanged", len(missing), len(changed), unchanged)
+		if len(missing)+len(changed) == 0 {
+			return
+		}
+		restored := metaModels(append(append([]api.Meta(nil), missing...), changed...))
+		guardBulk(handler, "restoring", restored, len(objects), restoreFlags.Force)
+		confirmBulk(handler, "restored", restored, restoreFlags.Yes)
+
+		// write full objects and report
+		report := handler.UpdateMetas(changed)
+		for i := range missing {
+			resp := handler.Client.DefaultResponse("created", handler.CreateMeta(&missing[i]))
+			key := "#" + strconv.Itoa(i+1)
+			if missing[i].Model.ID != 0 {
+				key = strconv.Itoa(missing[i].Model.ID)
+			}
+			report.Insert(key, &resp)
+		}
+		exportResponses(handler, restored, report)
+	},
+}
+
+// remapIDs replaces object IDs given as old=new pairs.
+func remapIDs(metas []api.Meta, pairs []string) error {
+	mapping := make(map[int]int)
+	for _, pair := range pairs {
+		parts := strings.SplitN(pair, "=", 2)
+		if len(parts) != 2 {
+			return fmt.Errorf("Invalid ID mapping %q, expected old=new.", pair)
+		}
+		from, err := strconv.Atoi(parts[0])
+		if err != nil {
+			return fmt.Errorf("Invalid ID mapping %q, expected old=new.", pair)
+		}
+		to, err := strconv.Atoi(parts[1])
+		if err != nil {
+			return fmt.Errorf("Invalid ID mapping %q, expected old=new.", pair)
+		}
+		mapping[from] = to
+	}
+
+	for i := range metas {
+		if to, ok := mapping[metas[i].Model.ID]; ok {
+			if err := metas[i].Set("id", strconv.Itoa(to)); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// modelIDs lists IDs of objects.
+func modelIDs(models []*api.Model) string {
+	var ids []string
+	for _, model := range models {
+		ids = append(ids, strconv.Itoa(model.ID))
+	}
+	return strings.Join(ids, ", ")
+}
+
+// backendObjects decodes raw objects into a map by their ID, so that they
+// can be compared with the backup.
+func backendObjects(objects []json.RawMessage) (map[int]map[string]interface{}, error) {
+	ret := make(map[int]map[string]interface{}, len(objects))
+	for _, raw := range objects {
+		var object map[string]interface{}
+		if err := json.Unmarshal(raw, &object); err != nil {
+			return nil, err
+		}
+		var model api.Model
+		if err := json.Unmarshal(raw, &model); err != nil {
+			return nil, err
+		}
+		ret[model.ID] = object
+	}
+	return ret, nil
+}
+
+func init() {
+	restoreCmd.Flags().StringVar(&restoreFlags.Model, "model", "", "Restore into another model than the one in backup")
+	restoreCmd.Flags().StringVar(&restoreFlags.OnConflict, "on-conflict", conflictSkip, "Policy for existing objects which differ from backup (skip, overwrite, fail)")
+	restoreCmd.Flags().StringArrayVar(&restoreFlags.MapIDs, "map-id", nil, "Restore object under another ID as old=new, e.g. --map-id 5=105 (repeatable)")
+	addDryRunFlag(restoreCmd, &restoreFlags.DryRun)
+	addYesFlag(restoreCmd, &restoreFlags.Yes)
+	addForceFlag(restoreCmd, &restoreFlags.Force)
+	rootCmd.AddCommand(restoreCmd)
+}
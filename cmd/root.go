@@ -0,0 +1,205 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+
+	cfg "github.com/fhivemind/go-hastily/config"
+	"github.com/fhivemind/go-hastily/log"
+	"github.com/fhivemind/go-hastily/pkg/api"
+	"github.com/fhivemind/go-hastily/pkg/common"
+	. "github.com/fhivemind/go-hastily/pkg/global"
+	"github.com/fhivemind/go-hastily/pkg/transport"
+	"github.com/fhivemind/go-hastily/pkg/version"
+	"github.com/spf13/cobra"
+)
+
+// rootCmd is the base command of the CLI.
+var rootCmd = &cobra.Command{
+	Use:           "go-hastily",
+	Short:         "Advanced CLI client for RESTful Go development.",
+	Version:       version.Version,
+	SilenceErrors: true,
+	SilenceUsage:  true,
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		err := cfg.Reload(cfg.Options{
+			File:      rootFlags.ConfigFile,
+			Overrides: rootFlags.Overrides,
+			Context:   rootFlags.Context,
+		})
+		log.Configure(cfg.Config(), rootFlags.Verbose)
+		if mwErr := useMiddlewares(); mwErr != nil {
+			return mwErr
+		}
+
+		// config commands must work with broken config to fix it
+		if err != nil && cmd.Parent() == configCmd {
+			CLI.Warn("%v", err)
+			return nil
+		}
+		return err
+	},
+}
+
+// rootFlags holds options shared by all commands.
+var rootFlags struct {
+	Context    string
+	ConfigFile string
+	Overrides  []string
+	Verbose    int
+	HAR        string
+	Record     string
+	Replay     string
+	NoCache    bool
+}
+
+func init() {
+	rootCmd.PersistentFlags().StringVar(&rootFlags.Context, "context", "", "Config context to use")
+	rootCmd.PersistentFlags().StringVar(&rootFlags.ConfigFile, "config-file", "", "Additional config file with higher priority than other files")
+	rootCmd.PersistentFlags().StringArrayVar(&rootFlags.Overrides, "set-config", nil, "Override config value, e.g. --set-config http.timeout=10s")
+	rootCmd.PersistentFlags().CountVarP(&rootFlags.Verbose, "verbose", "v", "Trace requests: -v status and latency, -vv headers, -vvv bodies")
+	rootCmd.PersistentFlags().StringVar(&rootFlags.HAR, "har", "", "Record every HTTP exchange into HAR file, e.g. --har out.har")
+	rootCmd.PersistentFlags().StringVar(&rootFlags.Record, "record", "", "Save every HTTP exchange into cassette directory")
+	rootCmd.PersistentFlags().StringVar(&rootFlags.Replay, "replay", "", "Serve HTTP exchanges from cassette directory without network")
+	rootCmd.PersistentFlags().BoolVar(&rootFlags.NoCache, "no-cache", false, "Bypass on-disk cache of GET responses")
+}
+
+// useMiddlewares registers transport middlewares requested by flags.
+func useMiddlewares() error {
+	if rootFlags.Record != "" && rootFlags.Replay != "" {
+		return errors.New("Use either --record or --replay, not both.")
+	}
+	if rootFlags.HAR != "" {
+		recorder := transport.NewHARRecorder(rootFlags.HAR)
+		transport.Use(recorder.Middleware())
+		OnExit(func() {
+			if err := recorder.Close(); err != nil {
+				CLI.Error("Unable to write HAR file %s: %v", rootFlags.HAR, err)
+			}
+		})
+	}
+	if rootFlags.Record != "" {
+		transport.Use(transport.Record(rootFlags.Record))
+	}
+	if rootFlags.Replay != "" {
+		transport.Use(transport.Replay(rootFlags.Replay))
+	}
+	return nil
+}
+
+// Execute runs the root command and handles its errors.
+func Execute() {
+	HandleError(rootCmd.Execute())
+	RunExitHooks()
+}
+
+// addDryRunFlag registers dry-run flag on mutating commands.
+func addDryRunFlag(cmd *cobra.Command, target *string) {
+	cmd.Flags().StringVar(target, "dry-run", "", "Only print (client) or flag (server) mutating requests instead of applying them")
+	cmd.Flags().Lookup("dry-run").NoOptDefVal = string(api.DryRunClient)
+}
+
+// newAPI creates API handler for a model with the requested dry-run strategy.
+func newAPI(model string, dryRun string) *api.ApiModel {
+	strategy, err := api.ParseDryRun(dryRun)
+	HandleError(err)
+
+	handler := api.NewAPI(model)
+	handler.Client.DryRun = strategy
+	if rootFlags.NoCache {
+		handler.Client.Cache = nil
+	}
+	return &handler
+}
+
+// exportResponses prints results of bulk operations.
+func exportResponses(handler *api.ApiModel, models []*api.Model, resp *api.ResponseList) {
+	CLI.Subtitle("%d/%d requests successful", resp.Successes(), resp.Size())
+	HandleError(handler.Export(api.ExportModel{
+		Data:        models,
+		ExtraFields: resp.ToGeneric(),
+		Type:        common.Tabler.Basic,
+	}))
+}
+
+// addForceFlag registers flag which allows operations in forbidden contexts.
+func addForceFlag(cmd *cobra.Command, target *bool) {
+	cmd.Flags().BoolVar(target, "i-mean-it", false, "Allow bulk operations in forbidden contexts")
+}
+
+// guardBulk aborts the command if bulk operation violates safeguards.
+// In client dry-run mode violations are only reported.
+func guardBulk(handler *api.ApiModel, action string, models []*api.Model, total int, force bool) {
+	err := handler.CheckSafeguards(action, models, total, force)
+	if err != nil && handler.Client.DryRun == api.DryRunClient {
+		CLI.Warn("%v", err)
+		return
+	}
+	HandleError(err)
+}
+
+// addYesFlag registers flag which skips confirmation prompts.
+func addYesFlag(cmd *cobra.Command, target *bool) {
+	cmd.Flags().BoolVarP(target, "yes", "y", false, "Skip confirmation prompt")
+}
+
+// confirmBulk previews affected objects and asks user to confirm the operation.
+// The command exits if user declines.
+func confirmBulk(handler *api.ApiModel, action string, models []*api.Model, yes bool) {
+	if yes || len(models) == 0 || handler.Client.DryRun == api.DryRunClient {
+		return
+	}
+
+	// stdin may be used up by input files or pipes
+	if !common.IsInteractive() {
+		HandleErrorMessage("Unable to ask for confirmation, stdin is not a terminal. Pass --yes to proceed.")
+	}
+
+	// preview
+	CLI.Subtitle("The following objects will be %s:", action)
+	HandleError(handler.Export(api.ExportModel{
+		Data: models,
+		Type: common.Tabler.Preview,
+	}))
+
+	// confirm
+	ok, err := common.Confirm(fmt.Sprintf("%d %s objects will be %s. Continue", len(models), handler.Name, action))
+	HandleError(err)
+	if !ok {
+		CLI.Warn("Aborted, no changes made.")
+		Exit(0)
+	}
+}
+
+// changedModels returns models which were successfully updated.
+func changedModels(models []*api.Model, statuses *common.StatusList) (ret []*api.Model) {
+	for _, model := range models {
+		if status, ok := statuses.Get(strconv.Itoa(model.ID)); ok && status.Success {
+			ret = append(ret, model)
+		}
+	}
+	return
+}
+
+// metaModels returns models of Meta objects.
+func metaModels(metas []api.Meta) []*api.Model {
+	models := make([]*api.Model, 0, len(metas))
+	for i := range metas {
+		models = append(models, &metas[i].Model)
+	}
+	return models
+}
+
+// addSetFlag registers flag which overrides values of loaded manifests.
+func addSetFlag(cmd *cobra.Command, target *[]string) {
+	cmd.Flags().StringArrayVar(target, "set", nil, "Override manifest value as key=value, e.g. address.city=Paris (repeatable)")
+}
+
+// loadManifests loads objects from file and applies --set overrides.
+func loadManifests(file string, overrides []string) []api.Meta {
+	metas, err := api.LoadMetas(file)
+	HandleError(err)
+	HandleError(api.ApplyOverrides(metas, overrides))
+	return metas
+}
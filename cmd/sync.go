@@ -0,0 +1,28 @@
+package cmd
+
+import (
+	. "github.com/fhivemind/go-hastily/pkg/global"
+	"github.com/spf13/cobra"
+)
+
+var syncCmd = &cobra.Command{
+	Use:   "sync <model>...",
+	Short: "Snapshot all objects of models into local store",
+	Long: `Snapshot all objects of models into local store.
+
+Snapshots are kept per context and replaced on every sync. Query them
+without network round trips with get --offline.`,
+	Args: cobra.MinimumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		for _, model := range args {
+			handler := newAPI(model, "")
+			snapshot, err := handler.Sync()
+			HandleError(err)
+			CLI.Success("Synced %d %s into %s.", len(snapshot.Objects), model, snapshot.Path)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(syncCmd)
+}
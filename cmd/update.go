@@ -0,0 +1,84 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/fhivemind/go-hastily/pkg/api"
+	"github.com/fhivemind/go-hastily/pkg/common"
+	. "github.com/fhivemind/go-hastily/pkg/global"
+	"github.com/spf13/cobra"
+)
+
+// updateFlags holds options of update command.
+var updateFlags struct {
+	ID     int
+	File   string
+	Set    []string
+	DryRun string
+	Yes    bool
+	Force  bool
+}
+
+var updateCmd = &cobra.Command{
+	Use:   "update <model>",
+	Short: "Update objects matching a filter with values from file",
+	Long: `Update objects matching a filter with values from file.
+
+If the file holds a single object, it is merged into every object matching
+the filter. If it holds multiple objects, each one is merged into the
+object with the same ID.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		metas := loadManifests(updateFlags.File, updateFlags.Set)
+
+		if len(metas) > 1 {
+			for i := range metas {
+				if metas[i].Model.ID == 0 {
+					HandleErrorMessage(fmt.Sprintf("Object #%d has no ID. Multiple objects are matched by ID.", i+1))
+				}
+			}
+		}
+
+		handler := newAPI(args[0], updateFlags.DryRun)
+		all, err := handler.GetAll()
+		HandleError(err)
+		models := handler.ListFilter(all, &api.Filter{ID: updateFlags.ID})
+
+		updated, statuses := updateFromMetas(handler, models, metas, len(metas) > 1)
+		changed := changedModels(updated, statuses)
+		guardBulk(handler, "updating", changed, len(all), updateFlags.Force)
+		confirmBulk(handler, "updated", changed, updateFlags.Yes)
+		exportResponses(handler, updated, handler.UpdateMany(updated, statuses))
+	},
+}
+
+// updateFromMetas merges sources into models. If matchID is set, each source
+// is only merged into the model with the same ID.
+func updateFromMetas(handler *api.ApiModel, models []*api.Model, metas []api.Meta, matchID bool) ([]*api.Model, *common.StatusList) {
+	if !matchID && len(metas) == 1 {
+		return handler.ListUpdate(models, &metas[0])
+	}
+
+	var updated []*api.Model
+	statuses := common.NewStatusList()
+	for i := range metas {
+		targets := handler.ListFilter(models, &api.Filter{ID: metas[i].Model.ID})
+		dests, resp := handler.ListUpdate(targets, &metas[i])
+		updated = append(updated, dests...)
+		for key, status := range resp.Data {
+			statuses.Insert(key, status)
+		}
+	}
+	return updated, statuses
+}
+
+func init() {
+	updateCmd.Flags().IntVar(&updateFlags.ID, "id", 0, "Only update object with this ID")
+	updateCmd.Flags().StringVarP(&updateFlags.File, "file", "f", "", "YAML or JSON file with values to update, - for stdin")
+	updateCmd.MarkFlagRequired("file")
+	addSetFlag(updateCmd, &updateFlags.Set)
+	addDryRunFlag(updateCmd, &updateFlags.DryRun)
+	addYesFlag(updateCmd, &updateFlags.Yes)
+	addForceFlag(updateCmd, &updateFlags.Force)
+	rootCmd.AddCommand(updateCmd)
+}
@@ -2,16 +2,133 @@ package config
 
 import (
 	"fmt"
+	"sort"
+	"sync"
 	"time"
-
-	"github.com/spf13/viper"
 )
 
 // config struct holds various configuration options.
 type config struct {
-	ApiEndpoint    string `yaml:"api"`
-	LoginEndpoint  string `yaml:"login"`
-	VerifyEndpoint string `yaml:"verify"`
+	Version        int                `yaml:"version"`
+	ApiEndpoint    string             `yaml:"api" schema:"url"`
+	LoginEndpoint  string             `yaml:"login" schema:"url"`
+	VerifyEndpoint string             `yaml:"verify" schema:"url"`
+	DryRunParam    string             `yaml:"dry_run_param"`
+	DryRunHeader   string             `yaml:"dry_run_header"`
+	Headers        map[string]string  `yaml:"headers"`
+	Safeguards     safeguards         `yaml:"safeguards"`
+	Credentials    credentials        `yaml:"credentials"`
+	OAuth          oauth              `yaml:"oauth"`
+	Auth           authMode           `yaml:"auth"`
+	TLS            tlsSettings        `yaml:"tls"`
+	HTTP           httpSettings       `yaml:"http"`
+	Log            logSettings        `yaml:"log"`
+	Cache          cacheSettings      `yaml:"cache"`
+	Pagination     pagination         `yaml:"pagination"`
+	Copy           copySettings       `yaml:"copy"`
+	Context        string             `yaml:"context"`
+	Contexts       map[string]*config `yaml:"contexts"`
+}
+
+// safeguards struct holds limits for bulk destructive operations.
+type safeguards struct {
+	MaxObjects        int               `yaml:"max_objects"`
+	MaxPercent        float64           `yaml:"max_percent"`
+	ForbiddenContexts []string          `yaml:"forbidden_contexts"`
+	ProtectedIDs      []int             `yaml:"protected_ids"`
+	ProtectedLabels   map[string]string `yaml:"protected_labels"`
+}
+
+// credentials struct selects where login credentials are stored.
+type credentials struct {
+	Store   string `yaml:"store" schema:"oneof=file encrypted helper"`
+	KeyFile string `yaml:"key_file"`
+	Helper  string `yaml:"helper"`
+}
+
+// oauth struct holds OAuth client settings for interactive logins.
+type oauth struct {
+	ClientID           string   `yaml:"client_id"`
+	Scopes             []string `yaml:"scopes"`
+	TokenEndpoint      string   `yaml:"token_endpoint" schema:"url"`
+	DeviceEndpoint     string   `yaml:"device_endpoint" schema:"url"`
+	AuthorizeEndpoint  string   `yaml:"authorize_endpoint" schema:"url"`
+	RevocationEndpoint string   `yaml:"revocation_endpoint" schema:"url"`
+	RedirectPort       int      `yaml:"redirect_port"`
+}
+
+// authMode struct selects how requests to backend are authenticated.
+// Secrets are always read from environment variables.
+type authMode struct {
+	Mode            string  `yaml:"mode" schema:"oneof=login client_credentials api_key basic bearer hmac"`
+	ClientSecretEnv string  `yaml:"client_secret_env"`
+	TokenEnv        string  `yaml:"token_env"`
+	UsernameEnv     string  `yaml:"username_env"`
+	PasswordEnv     string  `yaml:"password_env"`
+	Header          string  `yaml:"header"`
+	Query           string  `yaml:"query"`
+	Signing         signing `yaml:"signing"`
+}
+
+// signing struct configures HMAC request signing of the hmac mode.
+type signing struct {
+	KeyID           string   `yaml:"key_id"`
+	SecretEnv       string   `yaml:"secret_env"`
+	Header          string   `yaml:"header"`
+	KeyIDHeader     string   `yaml:"key_id_header"`
+	TimestampHeader string   `yaml:"timestamp_header"`
+	SignedHeaders   []string `yaml:"signed_headers"`
+	Encoding        string   `yaml:"encoding" schema:"oneof=hex base64"`
+	TimestampFormat string   `yaml:"timestamp_format" schema:"oneof=unix rfc3339"`
+}
+
+// tlsSettings struct holds TLS options of backend connections.
+type tlsSettings struct {
+	CAFile             string `yaml:"ca_file"`
+	CertFile           string `yaml:"cert_file"`
+	KeyFile            string `yaml:"key_file"`
+	MinVersion         string `yaml:"min_version" schema:"oneof=1.0 1.1 1.2 1.3"`
+	ServerName         string `yaml:"server_name"`
+	InsecureSkipVerify bool   `yaml:"insecure_skip_verify"`
+}
+
+// httpSettings struct holds connection, timeout and proxy options of backend connections.
+type httpSettings struct {
+	Timeout               time.Duration `yaml:"timeout"`
+	DialTimeout           time.Duration `yaml:"dial_timeout"`
+	TLSHandshakeTimeout   time.Duration `yaml:"tls_handshake_timeout"`
+	ResponseHeaderTimeout time.Duration `yaml:"response_header_timeout"`
+	MaxIdleConnsPerHost   int           `yaml:"max_idle_conns_per_host"`
+	DisableHTTP2          bool          `yaml:"disable_http2"`
+	Proxy                 string        `yaml:"proxy" schema:"proxy"`
+	NoProxy               string        `yaml:"no_proxy"`
+	UnixSocket            string        `yaml:"unix_socket"`
+}
+
+// logSettings struct holds logging options.
+type logSettings struct {
+	Level        string   `yaml:"level" schema:"oneof=debug info warning error"`
+	JSON         bool     `yaml:"json"`
+	RedactFields []string `yaml:"redact_fields"`
+}
+
+// cacheSettings struct holds options of the on-disk HTTP cache.
+type cacheSettings struct {
+	Disabled  bool   `yaml:"disabled"`
+	Dir       string `yaml:"dir"`
+	MaxSizeMB int    `yaml:"max_size_mb"`
+}
+
+// pagination struct names query params used to page through collections.
+type pagination struct {
+	PageParam string `yaml:"page_param"`
+	SizeParam string `yaml:"size_param"`
+	PageSize  int    `yaml:"page_size"`
+}
+
+// copySettings struct holds options of copying objects between contexts.
+type copySettings struct {
+	StripFields []string `yaml:"strip_fields"`
 }
 
 // Provider defines a set of read-only methods for accessing the application
@@ -35,35 +152,101 @@ type Provider interface {
 	IsSet(key string) bool
 }
 
-// LoadConfig returns a configured viper instance
+var (
+	// baseConf holds configuration as read from all layers.
+	baseConf *config
+	// baseValues holds raw values of all layers, which contexts are merged into.
+	baseValues map[string]interface{}
+	// activeConf holds configuration with selected context applied.
+	activeConf *config
+	loadOnce   sync.Once
+)
+
+// LoadConfig returns the shared configuration with the selected context applied.
 func LoadConfig() *config {
-	v := readViperConfig("GO-HASTILY")
-	conf := &config{}
+	loadOnce.Do(func() {
+		baseConf = &config{}
+		activeConf = &config{}
+		// errors are reported when commands call Reload
+		reload(Options{})
+	})
+
+	return activeConf
+}
+
+// Reload reads configuration from all layers again, applying command line
+// options on top. The shared configuration is updated in place.
+func Reload(opts Options) error {
+	LoadConfig()
+	return reload(opts)
+}
+
+// reload reads all layers and updates the shared configuration.
+func reload(opts Options) error {
 
-	if err := v.Unmarshal(conf); err != nil {
-		fmt.Printf("unable to decode into config struct, %v", err)
+	// read layers
+	v, values, sources, err := readLayers(opts)
+	if err != nil {
+		return err
+	}
+	conf, err := decodeConfig(values)
+	if err != nil {
+		return fmt.Errorf("Unable to decode configuration: %v", err)
 	}
 
-	return conf
+	// update shared state
+	mutex.Lock()
+	provider, settingSources = v, sources
+	mutex.Unlock()
+	*baseConf = *conf
+	baseValues = values
+
+	// select context
+	name := conf.Context
+	if opts.Context != "" {
+		name = opts.Context
+	}
+	return applyContext(name)
 }
 
-func readViperConfig(appName string) *viper.Viper {
-	v := viper.New()
-	v.SetEnvPrefix(appName)
-	v.AutomaticEnv()
-	v.SetConfigName("config")
-	v.SetConfigType("yaml")
-	v.AddConfigPath(".")
+// UseContext switches the shared configuration to a named context.
+// Empty name selects no context, i.e. only top-level values.
+func UseContext(name string) error {
+	LoadConfig()
+	return applyContext(name)
+}
 
-	// global defaults
-	v.SetDefault("json_logs", false)
-	v.SetDefault("loglevel", "debug")
+// ContextNames lists all contexts defined in config.
+func ContextNames() []string {
+	LoadConfig()
+	var names []string
+	for name := range baseConf.Contexts {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
 
-	// read config
-	err := viper.ReadInConfig()
+// applyContext overrides base configuration with context values. Raw values
+// are merged before decoding, so that contexts can also reset values, e.g.
+// set a bool to false, and never share maps with base configuration.
+func applyContext(name string) error {
+	values := baseValues
+	if name != "" {
+		contexts, _ := asValues(baseValues["contexts"])
+		ctx, ok := asValues(contexts[name])
+		if !ok {
+			return fmt.Errorf("Context %q is not defined in config.", name)
+		}
+		values = mergeValues(baseValues, ctx)
+	}
+	active, err := decodeConfig(values)
 	if err != nil {
-		panic(fmt.Errorf("Fatal error config file: %s \n", err))
+		return fmt.Errorf("Unable to decode configuration: %v", err)
 	}
+	active.Context = name
 
-	return v
+	// update in place so that all holders see the change
+	*activeConf = *active
+	return nil
 }
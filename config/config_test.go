@@ -0,0 +1,71 @@
+package config
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestContextsKeepKeyCaseAndResetValues(t *testing.T) {
+	dir, err := ioutil.TempDir("", "config")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	defer os.Setenv("XDG_CONFIG_HOME", os.Getenv("XDG_CONFIG_HOME"))
+	os.Setenv("XDG_CONFIG_HOME", dir)
+
+	path := filepath.Join(dir, "test.yaml")
+	data := `version: 2
+headers:
+  X-Request-Source: cli
+tls:
+  insecure_skip_verify: true
+safeguards:
+  protected_labels:
+    Team: core
+contexts:
+  Prod:
+    headers:
+      X-Env: prod
+    tls:
+      insecure_skip_verify: false
+    safeguards:
+      protected_labels:
+        Tier: gold
+`
+	if err = ioutil.WriteFile(path, []byte(data), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err = Reload(Options{File: path, Context: "Prod"}); err != nil {
+		t.Fatal(err)
+	}
+	defer Reload(Options{})
+
+	conf := LoadConfig()
+	if want := map[string]string{"X-Request-Source": "cli", "X-Env": "prod"}; !reflect.DeepEqual(conf.Headers, want) {
+		t.Errorf("context headers = %v, want %v", conf.Headers, want)
+	}
+	if want := map[string]string{"Team": "core", "Tier": "gold"}; !reflect.DeepEqual(conf.Safeguards.ProtectedLabels, want) {
+		t.Errorf("context protected labels = %v, want %v", conf.Safeguards.ProtectedLabels, want)
+	}
+	if conf.TLS.InsecureSkipVerify {
+		t.Error("context did not reset tls.insecure_skip_verify to false")
+	}
+
+	// switching back leaves base values untouched
+	if err = UseContext(""); err != nil {
+		t.Fatal(err)
+	}
+	if want := map[string]string{"X-Request-Source": "cli"}; !reflect.DeepEqual(conf.Headers, want) {
+		t.Errorf("base headers = %v, want %v", conf.Headers, want)
+	}
+	if !conf.TLS.InsecureSkipVerify {
+		t.Error("base tls.insecure_skip_verify changed by context")
+	}
+	if names := ContextNames(); !reflect.DeepEqual(names, []string{"Prod"}) {
+		t.Errorf("ContextNames() = %v, want [Prod]", names)
+	}
+}
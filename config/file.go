@@ -0,0 +1,296 @@
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/fhivemind/go-hastily/pkg/common"
+	"gopkg.in/yaml.v3"
+)
+
+// CurrentVersion is the version of the config layout written by this release.
+const CurrentVersion = 2
+
+// migrations upgrade config layout from the version of their key to the next one.
+var migrations = map[int]func(*File){
+	// version 1 kept logging options at top level
+	1: func(file *File) {
+		file.move("loglevel", "log.level")
+		file.move("json_logs", "log.json")
+	},
+}
+
+// File is a yaml config file which is edited with comments preserved.
+type File struct {
+	Path string
+	doc  *yaml.Node
+}
+
+// OpenFile loads config file for editing. Missing file is treated as
+// empty file of the current version.
+func OpenFile(path string) (*File, error) {
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		data = []byte(fmt.Sprintf("version: %d\n", CurrentVersion))
+	} else if err != nil {
+		return nil, err
+	}
+	return parseFile(path, data)
+}
+
+// LoadFile reads config file for reading values, with ${...} references of
+// string values interpolated and layout migrated to the current version.
+func LoadFile(path string) (*File, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	file, err := parseFile(path, data)
+	if err != nil {
+		return nil, err
+	}
+	if err = interpolateNode(file.doc); err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	if _, err = file.Migrate(); err != nil {
+		return nil, err
+	}
+	return file, nil
+}
+
+// parseFile parses yaml data of a config file.
+func parseFile(path string, data []byte) (*File, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("Unable to parse config file %s: %v", path, err)
+	}
+	if doc.Kind == 0 {
+		doc = yaml.Node{Kind: yaml.DocumentNode, Content: []*yaml.Node{{Kind: yaml.MappingNode, Tag: "!!map"}}}
+	}
+	if doc.Kind != yaml.DocumentNode || len(doc.Content) != 1 || doc.Content[0].Kind != yaml.MappingNode {
+		return nil, fmt.Errorf("Config file %s must contain a yaml mapping.", path)
+	}
+	return &File{Path: path, doc: &doc}, nil
+}
+
+// interpolateNode resolves references in string scalars of node. Comments
+// and keys are left as they are.
+func interpolateNode(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode {
+		if node.ShortTag() != "!!str" {
+			return nil
+		}
+		value, err := common.Interpolate(node.Value)
+		if err != nil {
+			return err
+		}
+		node.Value = value
+		return nil
+	}
+	for i, child := range node.Content {
+		if node.Kind == yaml.MappingNode && i%2 == 0 {
+			continue
+		}
+		if err := interpolateNode(child); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Values decodes config file into a map.
+func (file *File) Values() (map[string]interface{}, error) {
+	values := make(map[string]interface{})
+	if err := file.doc.Decode(&values); err != nil {
+		return nil, err
+	}
+	return values, nil
+}
+
+// Get returns raw value of a key.
+func (file *File) Get(key string) (interface{}, bool) {
+	node := file.find(strings.Split(key, "."), false)
+	if node == nil {
+		return nil, false
+	}
+	var value interface{}
+	if node.Decode(&value) != nil {
+		return nil, false
+	}
+	return value, true
+}
+
+// Set validates raw value against the schema and sets the key.
+func (file *File) Set(key string, raw string) error {
+	value, err := ParseValue(key, raw)
+	if err != nil {
+		return err
+	}
+	return file.setValue(key, value)
+}
+
+// Unset removes a key. Returns false if the key was not set.
+func (file *File) Unset(key string) bool {
+	parts := strings.Split(key, ".")
+	parent := file.find(parts[:len(parts)-1], false)
+	if parent == nil || parent.Kind != yaml.MappingNode {
+		return false
+	}
+	for i := 0; i+1 < len(parent.Content); i += 2 {
+		if parent.Content[i].Value == parts[len(parts)-1] {
+			parent.Content = append(parent.Content[:i], parent.Content[i+2:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// Version returns layout version of the file. Files without version key are version 1.
+func (file *File) Version() int {
+	node := file.find([]string{"version"}, false)
+	if node == nil {
+		return 1
+	}
+	version, err := strconv.Atoi(node.Value)
+	if err != nil {
+		return 1
+	}
+	return version
+}
+
+// Migrate upgrades file layout to CurrentVersion. Returns the original version.
+func (file *File) Migrate() (int, error) {
+	from := file.Version()
+	if from > CurrentVersion {
+		return from, fmt.Errorf("Config file %s has version %d, which is newer than supported version %d.", file.Path, from, CurrentVersion)
+	}
+	for version := from; version < CurrentVersion; version++ {
+		if migrate, ok := migrations[version]; ok {
+			migrate(file)
+		}
+	}
+	if from != CurrentVersion {
+		return from, file.setValue("version", CurrentVersion)
+	}
+	return from, nil
+}
+
+// Save writes file atomically.
+func (file *File) Save() error {
+	var out bytes.Buffer
+	encoder := yaml.NewEncoder(&out)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(file.doc); err != nil {
+		return err
+	}
+	encoder.Close()
+
+	// write to temp file and rename into place
+	dir := filepath.Dir(file.Path)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	tmp, err := ioutil.TempFile(dir, ".tmp-"+filepath.Base(file.Path))
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err = tmp.Write(out.Bytes()); err == nil {
+		err = tmp.Sync()
+	}
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+	if info, err := os.Stat(file.Path); err == nil {
+		os.Chmod(tmp.Name(), info.Mode())
+	} else {
+		os.Chmod(tmp.Name(), 0644)
+	}
+	return os.Rename(tmp.Name(), file.Path)
+}
+
+// setValue sets typed value of a key, creating parent sections.
+func (file *File) setValue(key string, value interface{}) error {
+	var node yaml.Node
+	if err := node.Encode(value); err != nil {
+		return err
+	}
+	parts := strings.Split(key, ".")
+	parent := file.find(parts[:len(parts)-1], true)
+	if parent == nil || parent.Kind != yaml.MappingNode {
+		return fmt.Errorf("Cannot set %s, its parent is not a section.", key)
+	}
+
+	// replace existing value to keep comments of the key
+	name := parts[len(parts)-1]
+	for i := 0; i+1 < len(parent.Content); i += 2 {
+		if parent.Content[i].Value == name {
+			node.HeadComment = parent.Content[i+1].HeadComment
+			node.LineComment = parent.Content[i+1].LineComment
+			*parent.Content[i+1] = node
+			return nil
+		}
+	}
+	parent.Content = append(parent.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: name}, &node)
+	return nil
+}
+
+// find returns value node at path, optionally creating missing sections.
+func (file *File) find(path []string, create bool) *yaml.Node {
+	node := file.doc.Content[0]
+	for _, part := range path {
+		if node.Kind != yaml.MappingNode {
+			return nil
+		}
+		var next *yaml.Node
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			if node.Content[i].Value == part {
+				next = node.Content[i+1]
+				break
+			}
+		}
+		if next == nil {
+			if !create {
+				return nil
+			}
+			next = &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+			node.Content = append(node.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: part}, next)
+		}
+		node = next
+	}
+	return node
+}
+
+// move relocates a top-level key with its comments to another path.
+// If the new key already exists, the old one is dropped.
+func (file *File) move(from string, to string) {
+	root := file.doc.Content[0]
+	for i := 0; i+1 < len(root.Content); i += 2 {
+		if root.Content[i].Value != from {
+			continue
+		}
+		key, value := root.Content[i], root.Content[i+1]
+		root.Content = append(root.Content[:i], root.Content[i+2:]...)
+
+		// new key takes precedence over the old one
+		parts := strings.Split(to, ".")
+		if file.find(parts, false) != nil {
+			return
+		}
+		parent := file.find(parts[:len(parts)-1], true)
+		if parent == nil || parent.Kind != yaml.MappingNode {
+			return
+		}
+		key.Value = parts[len(parts)-1]
+		parent.Content = append(parent.Content, key, value)
+		return
+	}
+}
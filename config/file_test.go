@@ -0,0 +1,45 @@
+package config
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLoadFileInterpolatesStringValues(t *testing.T) {
+	dir, err := ioutil.TempDir("", "config")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	os.Setenv("CONFIG_TEST_HEADER", `a: "b" # c`)
+	os.Unsetenv("CONFIG_TEST_UNSET")
+
+	path := filepath.Join(dir, "config.yaml")
+	data := `version: 2
+# comments may mention ${CONFIG_TEST_UNSET}
+api: ${CONFIG_TEST_API:-https://example.com/api}
+headers:
+  X-Test: ${CONFIG_TEST_HEADER}
+safeguards:
+  max_objects: 5
+`
+	if err = ioutil.WriteFile(path, []byte(data), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	file, err := LoadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for key, want := range map[string]interface{}{
+		"api":                    "https://example.com/api",
+		"headers.X-Test":         `a: "b" # c`,
+		"safeguards.max_objects": 5,
+	} {
+		if got, _ := file.Get(key); got != want {
+			t.Errorf("Get(%q) = %#v, want %#v", key, got, want)
+		}
+	}
+}
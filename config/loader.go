@@ -0,0 +1,391 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"os/user"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/mitchellh/mapstructure"
+	"github.com/spf13/viper"
+)
+
+// EnvPrefix is prepended to environment variables overriding config keys,
+// e.g. GO_HASTILY_API overrides "api" and GO_HASTILY_TLS_CA_FILE "tls.ca_file".
+const EnvPrefix = "GO_HASTILY"
+
+// Config layers, from lowest to highest priority.
+const (
+	SourceDefault = "default"
+	SourceSystem  = "system"
+	SourceUser    = "user"
+	SourceProject = "project"
+	SourceFile    = "file"
+	SourceEnv     = "env"
+	SourceFlag    = "flag"
+)
+
+// systemConfigFile is the config file shared by all users.
+const systemConfigFile = "/etc/go-hastily/config.yaml"
+
+// projectConfigFiles are searched in the working directory.
+var projectConfigFiles = []string{".go-hastily.yaml", "config.yaml"}
+
+// Options holds command line settings applied on top of config files.
+type Options struct {
+	// File is an explicit config file loaded after project config.
+	File string
+	// Overrides are "key=value" pairs with the highest priority.
+	Overrides []string
+	// Context selects a context instead of the configured one.
+	Context string
+}
+
+// Setting describes a merged config value and the layer it came from.
+type Setting struct {
+	Key    string
+	Value  interface{}
+	Source string
+}
+
+var (
+	// provider holds merged configuration of all layers.
+	provider = viper.New()
+	// settingSources maps flattened keys to their layer.
+	settingSources = make(map[string]string)
+	mutex          sync.Mutex
+)
+
+// Config returns read-only access to the merged configuration.
+func Config() Provider {
+	LoadConfig()
+	mutex.Lock()
+	defer mutex.Unlock()
+	return provider
+}
+
+// Settings lists all merged config values with their sources, sorted by key.
+func Settings() []Setting {
+	LoadConfig()
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	var settings []Setting
+	for key, value := range flatten("", provider.AllSettings()) {
+		settings = append(settings, Setting{
+			Key:    key,
+			Value:  value,
+			Source: settingSources[key],
+		})
+	}
+	sort.Slice(settings, func(i, j int) bool { return settings[i].Key < settings[j].Key })
+	return settings
+}
+
+// Dir returns go-hastily directory inside $XDG_CONFIG_HOME.
+func Dir() (string, error) {
+	base := os.Getenv("XDG_CONFIG_HOME")
+	if base == "" {
+		myself, err := user.Current()
+		if err != nil {
+			return "", err
+		}
+		base = filepath.Join(myself.HomeDir, ".config")
+	}
+	return filepath.Join(base, "go-hastily"), nil
+}
+
+// CacheDir returns directory of cached data, following XDG base directories.
+func CacheDir() (string, error) {
+	base := os.Getenv("XDG_CACHE_HOME")
+	if base == "" {
+		myself, err := user.Current()
+		if err != nil {
+			return "", err
+		}
+		base = filepath.Join(myself.HomeDir, ".cache")
+	}
+	return filepath.Join(base, "go-hastily"), nil
+}
+
+// DataDir returns directory of local data, following XDG base directories.
+func DataDir() (string, error) {
+	base := os.Getenv("XDG_DATA_HOME")
+	if base == "" {
+		myself, err := user.Current()
+		if err != nil {
+			return "", err
+		}
+		base = filepath.Join(myself.HomeDir, ".local", "share")
+	}
+	return filepath.Join(base, "go-hastily"), nil
+}
+
+// ExpandPath replaces leading "~" of path with home directory of current user.
+func ExpandPath(path string) (string, error) {
+	if path != "~" && !strings.HasPrefix(path, "~/") {
+		return path, nil
+	}
+	myself, err := user.Current()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(myself.HomeDir, path[1:]), nil
+}
+
+// UserConfigFile returns path of the config file of current user.
+func UserConfigFile() (string, error) {
+	dir, err := Dir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "config.yaml"), nil
+}
+
+// EnvName returns environment variable overriding a config key.
+func EnvName(key string) string {
+	return EnvPrefix + "_" + strings.ToUpper(strings.NewReplacer(".", "_", "-", "_").Replace(key))
+}
+
+// readLayers merges defaults, config files, environment and overrides. Values
+// are merged twice: by viper, which serves the Provider but lowercases all
+// keys, and into raw values which keep the case of map keys such as header
+// names and are decoded into config.
+func readLayers(opts Options) (*viper.Viper, map[string]interface{}, map[string]string, error) {
+	v := viper.New()
+	values := make(map[string]interface{})
+	sources := make(map[string]string)
+
+	// global defaults
+	defaults := map[string]interface{}{
+		"version":   CurrentVersion,
+		"log.json":  false,
+		"log.level": "debug",
+	}
+	for key, value := range defaults {
+		v.SetDefault(key, value)
+		values = setValue(values, key, value)
+		sources[key] = SourceDefault
+	}
+
+	// config files
+	for _, layer := range configFiles(opts) {
+		file, err := LoadFile(layer.path)
+		if os.IsNotExist(err) && !layer.required {
+			continue
+		}
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		fileValues, err := file.Values()
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		// viper lowercases keys of the map it merges
+		values = mergeValues(values, fileValues)
+		if err = v.MergeConfigMap(fileValues); err != nil {
+			return nil, nil, nil, err
+		}
+		for key := range flatten("", fileValues) {
+			sources[key] = fmt.Sprintf("%s:%s", layer.source, layer.path)
+		}
+		v.SetConfigFile(layer.path)
+	}
+
+	// environment
+	for _, key := range structKeys("", reflect.TypeOf(config{})) {
+		name := EnvName(key)
+		v.BindEnv(key, name)
+		if value, ok := os.LookupEnv(name); ok {
+			values = setValue(values, key, value)
+			sources[key] = fmt.Sprintf("%s:%s", SourceEnv, name)
+		}
+	}
+
+	// command line overrides
+	for _, override := range opts.Overrides {
+		parts := strings.SplitN(override, "=", 2)
+		if len(parts) != 2 || parts[0] == "" {
+			return nil, nil, nil, fmt.Errorf("Invalid config override %q, expected key=value.", override)
+		}
+		key := strings.ToLower(parts[0])
+		v.Set(key, parts[1])
+		values = setValue(values, parts[0], parts[1])
+		sources[key] = SourceFlag
+	}
+
+	return v, values, sources, nil
+}
+
+// configLayer defines a config file of a layer.
+type configLayer struct {
+	source   string
+	path     string
+	required bool
+}
+
+// ConfigFiles lists existing config files in order of priority.
+func ConfigFiles(opts Options) []string {
+	var files []string
+	for _, layer := range configFiles(opts) {
+		if _, err := os.Stat(layer.path); err == nil {
+			files = append(files, layer.path)
+		}
+	}
+	return files
+}
+
+// configFiles lists config files in order of priority.
+func configFiles(opts Options) []configLayer {
+	layers := []configLayer{{source: SourceSystem, path: systemConfigFile}}
+	if path, err := UserConfigFile(); err == nil {
+		layers = append(layers, configLayer{source: SourceUser, path: path})
+	}
+	for _, name := range projectConfigFiles {
+		if _, err := os.Stat(name); err == nil {
+			layers = append(layers, configLayer{source: SourceProject, path: name})
+			break
+		}
+	}
+
+	// explicit file
+	file := opts.File
+	if file == "" {
+		file = os.Getenv(EnvPrefix + "_CONFIG")
+	}
+	if file != "" {
+		layers = append(layers, configLayer{source: SourceFile, path: file, required: true})
+	}
+	return layers
+}
+
+// decodeConfig decodes merged raw values into config using yaml tags. Strings,
+// e.g. from environment, are converted like viper does.
+func decodeConfig(values map[string]interface{}) (*config, error) {
+	conf := &config{}
+	decoder, err := mapstructure.NewDecoder(&mapstructure.DecoderConfig{
+		TagName:          "yaml",
+		WeaklyTypedInput: true,
+		Result:           conf,
+		DecodeHook: mapstructure.ComposeDecodeHookFunc(
+			mapstructure.StringToTimeDurationHookFunc(),
+			mapstructure.StringToSliceHookFunc(","),
+		),
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err = decoder.Decode(values); err != nil {
+		return nil, err
+	}
+	return conf, nil
+}
+
+// mergeValues returns a copy of base with overlay merged on top. Nested maps
+// are merged and other values replaced. Keys match regardless of case, and
+// the case of overlay wins.
+func mergeValues(base map[string]interface{}, overlay map[string]interface{}) map[string]interface{} {
+	merged := make(map[string]interface{}, len(base))
+	for key, value := range base {
+		if nested, ok := asValues(value); ok {
+			value = mergeValues(nested, nil)
+		}
+		merged[key] = value
+	}
+	for key, value := range overlay {
+		existing, _ := takeValue(merged, key)
+		nested, isMap := asValues(value)
+		if current, ok := asValues(existing); ok && isMap {
+			value = mergeValues(current, nested)
+		} else if isMap {
+			value = mergeValues(nested, nil)
+		}
+		merged[key] = value
+	}
+	return merged
+}
+
+// setValue returns a copy of values with a dot-separated key set.
+func setValue(values map[string]interface{}, key string, value interface{}) map[string]interface{} {
+	parts := strings.Split(key, ".")
+	for i := len(parts) - 1; i >= 0; i-- {
+		value = map[string]interface{}{parts[i]: value}
+	}
+	return mergeValues(values, value.(map[string]interface{}))
+}
+
+// takeValue removes key from values, matching it regardless of case.
+func takeValue(values map[string]interface{}, key string) (interface{}, bool) {
+	for existing, value := range values {
+		if strings.EqualFold(existing, key) {
+			delete(values, existing)
+			return value, true
+		}
+	}
+	return nil, false
+}
+
+// asValues converts decoded yaml map into a map with string keys.
+func asValues(value interface{}) (map[string]interface{}, bool) {
+	switch typed := value.(type) {
+	case map[string]interface{}:
+		return typed, true
+	case map[interface{}]interface{}:
+		converted := make(map[string]interface{}, len(typed))
+		for key, nested := range typed {
+			converted[fmt.Sprintf("%v", key)] = nested
+		}
+		return converted, true
+	}
+	return nil, false
+}
+
+// structKeys lists flattened keys of all scalar and slice fields of a struct type.
+// Map fields, like contexts, cannot be set from the environment.
+func structKeys(prefix string, t reflect.Type) []string {
+	var keys []string
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name := strings.Split(field.Tag.Get("yaml"), ",")[0]
+		if name == "" || name == "-" {
+			continue
+		}
+		switch field.Type.Kind() {
+		case reflect.Struct:
+			keys = append(keys, structKeys(prefix+name+".", field.Type)...)
+		case reflect.Map:
+		default:
+			keys = append(keys, prefix+name)
+		}
+	}
+	return keys
+}
+
+// flatten converts nested maps into a map of dot-separated keys.
+func flatten(prefix string, values map[string]interface{}) map[string]interface{} {
+	flat := make(map[string]interface{})
+	for key, value := range values {
+		key = prefix + strings.ToLower(key)
+		switch nested := value.(type) {
+		case map[string]interface{}:
+			for k, v := range flatten(key+".", nested) {
+				flat[k] = v
+			}
+		case map[interface{}]interface{}:
+			converted := make(map[string]interface{})
+			for k, v := range nested {
+				converted[fmt.Sprintf("%v", k)] = v
+			}
+			for k, v := range flatten(key+".", converted) {
+				flat[k] = v
+			}
+		default:
+			flat[key] = value
+		}
+	}
+	return flat
+}
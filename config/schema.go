@@ -0,0 +1,177 @@
+package config
+
+import (
+	"fmt"
+	"net/url"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// durationType is used to detect duration fields.
+var durationType = reflect.TypeOf(time.Duration(0))
+
+// fieldType returns type and schema rule of a config key. Keys of contexts are
+// resolved against the top-level layout, e.g. "contexts.prod.api" as "api".
+func fieldType(key string) (reflect.Type, string, error) {
+	t := reflect.TypeOf(config{})
+	rule := ""
+	parts := strings.Split(strings.ToLower(key), ".")
+	for i := 0; i < len(parts); i++ {
+		switch t.Kind() {
+		case reflect.Struct:
+			field, ok := structField(t, parts[i])
+			if !ok {
+				return nil, "", fmt.Errorf("unknown key %q", key)
+			}
+			t, rule = field.Type, field.Tag.Get("schema")
+		case reflect.Map:
+			// map keys are free-form, e.g. context names or labels
+			t, rule = t.Elem(), ""
+		default:
+			return nil, "", fmt.Errorf("unknown key %q", key)
+		}
+		if t.Kind() == reflect.Ptr {
+			t = t.Elem()
+		}
+	}
+	return t, rule, nil
+}
+
+// structField finds struct field by its yaml name.
+func structField(t reflect.Type, name string) (reflect.StructField, bool) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if strings.Split(field.Tag.Get("yaml"), ",")[0] == name {
+			return field, true
+		}
+	}
+	return reflect.StructField{}, false
+}
+
+// ParseValue converts raw string into the type of a config key and checks
+// its schema rule. Lists are given as comma-separated values.
+func ParseValue(key string, raw string) (interface{}, error) {
+	t, rule, err := fieldType(key)
+	if err != nil {
+		return nil, err
+	}
+
+	// convert
+	var value interface{}
+	switch {
+	case t == durationType:
+		if _, err = time.ParseDuration(raw); err != nil {
+			return nil, fmt.Errorf("%s: expected a duration like 30s, got %q", key, raw)
+		}
+		value = raw
+	case t.Kind() == reflect.String:
+		value = raw
+	case t.Kind() == reflect.Bool:
+		if value, err = strconv.ParseBool(raw); err != nil {
+			return nil, fmt.Errorf("%s: expected true or false, got %q", key, raw)
+		}
+	case t.Kind() == reflect.Int:
+		if value, err = strconv.Atoi(raw); err != nil {
+			return nil, fmt.Errorf("%s: expected an integer, got %q", key, raw)
+		}
+	case t.Kind() == reflect.Float64:
+		if value, err = strconv.ParseFloat(raw, 64); err != nil {
+			return nil, fmt.Errorf("%s: expected a number, got %q", key, raw)
+		}
+	case t.Kind() == reflect.Slice:
+		var items []interface{}
+		for _, item := range strings.Split(raw, ",") {
+			if item = strings.TrimSpace(item); item == "" {
+				continue
+			}
+			if t.Elem().Kind() == reflect.Int {
+				n, err := strconv.Atoi(item)
+				if err != nil {
+					return nil, fmt.Errorf("%s: expected a list of integers, got %q", key, raw)
+				}
+				items = append(items, n)
+			} else {
+				items = append(items, item)
+			}
+		}
+		value = items
+	default:
+		return nil, fmt.Errorf("%s is a section, set its keys individually", key)
+	}
+
+	// check rule
+	if str, ok := value.(string); ok && str != "" {
+		if err = checkRule(key, rule, str); err != nil {
+			return nil, err
+		}
+	}
+	return value, nil
+}
+
+// checkRule validates string value against schema rule.
+func checkRule(key string, rule string, value string) error {
+	switch {
+	case rule == "url" || rule == "proxy":
+		schemes := []string{"http", "https"}
+		if rule == "proxy" {
+			schemes = append(schemes, "socks5")
+		}
+		u, err := url.Parse(value)
+		if err != nil {
+			return fmt.Errorf("%s: invalid URL %q: %v", key, value, err)
+		}
+		if !containsString(schemes, u.Scheme) {
+			return fmt.Errorf("%s: URL %q must use one of %s schemes", key, value, strings.Join(schemes, ", "))
+		}
+		if u.Host == "" {
+			return fmt.Errorf("%s: URL %q has no host", key, value)
+		}
+	case strings.HasPrefix(rule, "oneof="):
+		allowed := strings.Fields(strings.TrimPrefix(rule, "oneof="))
+		if containsString(allowed, value) {
+			return nil
+		}
+		return fmt.Errorf("%s: %q is not one of %s", key, value, strings.Join(allowed, ", "))
+	}
+	return nil
+}
+
+// ValidateValues checks all values of a parsed config file against the schema.
+func ValidateValues(values map[string]interface{}) []error {
+	var errs []error
+	for key, value := range flatten("", values) {
+		if value == nil {
+			continue
+		}
+		if _, err := ParseValue(key, valueToString(value)); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	sort.Slice(errs, func(i, j int) bool { return errs[i].Error() < errs[j].Error() })
+	return errs
+}
+
+// valueToString formats parsed yaml value the way ParseValue expects it.
+func valueToString(value interface{}) string {
+	if items, ok := value.([]interface{}); ok {
+		parts := make([]string, len(items))
+		for i, item := range items {
+			parts[i] = fmt.Sprintf("%v", item)
+		}
+		return strings.Join(parts, ",")
+	}
+	return fmt.Sprintf("%v", value)
+}
+
+// containsString checks if value is in values.
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
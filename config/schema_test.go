@@ -0,0 +1,32 @@
+package config
+
+import "testing"
+
+func TestCheckRule(t *testing.T) {
+	tests := []struct {
+		name    string
+		rule    string
+		value   string
+		wantErr bool
+	}{
+		{"http url", "url", "http://example.com/api", false},
+		{"https url", "url", "https://example.com", false},
+		{"socks5 url", "url", "socks5://proxy:1080", true},
+		{"url without host", "url", "https://", true},
+		{"url without scheme", "url", "example.com", true},
+		{"http proxy", "proxy", "http://proxy:3128", false},
+		{"socks5 proxy", "proxy", "socks5://proxy:1080", false},
+		{"ftp proxy", "proxy", "ftp://proxy", true},
+		{"allowed option", "oneof=file encrypted helper", "encrypted", false},
+		{"unknown option", "oneof=file encrypted helper", "vault", true},
+		{"no rule", "", "anything", false},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := checkRule("key", test.rule, test.value)
+			if (err != nil) != test.wantErr {
+				t.Errorf("checkRule(%q, %q) error = %v, want error %v", test.rule, test.value, err, test.wantErr)
+			}
+		})
+	}
+}
@@ -3,8 +3,8 @@ package log
 import (
 	"os"
 
-	"github.com/sirupsen/logrus"
 	"github.com/fhivemind/go-hastily/config"
+	"github.com/sirupsen/logrus"
 )
 
 // Logger defines a set of methods for writing application logs. Derived from and
@@ -36,40 +36,59 @@ type Logger interface {
 	Warnln(args ...interface{})
 }
 
-var defaultLogger *logrus.Logger
+var (
+	defaultLogger *logrus.Logger
+	verbosity     int
+)
 
 func init() {
 	defaultLogger = newLogrusLogger(config.Config())
 }
 
-
 // NewLogger returns a configured logrus instance
 func NewLogger(cfg config.Provider) *logrus.Logger {
 	return newLogrusLogger(cfg)
 }
 
+// Configure applies configuration to the default logger. Any verbosity
+// above zero enables debug level so that request traces are shown.
+func Configure(cfg config.Provider, verbose int) {
+	configured := newLogrusLogger(cfg)
+	defaultLogger.Formatter = configured.Formatter
+	defaultLogger.Level = configured.Level
+	if verbose > 0 {
+		defaultLogger.Level = logrus.DebugLevel
+	}
+	verbosity = verbose
+}
 
+// Verbosity returns the requested level of request tracing.
+func Verbosity() int {
+	return verbosity
+}
 
 func newLogrusLogger(cfg config.Provider) *logrus.Logger {
 
 	l := logrus.New()
-	
-	if cfg.GetBool("json_logs") {
+
+	if cfg.GetBool("log.json") {
 		l.Formatter = new(logrus.JSONFormatter)
 	}
 	l.Out = os.Stderr
 
-	switch cfg.GetString("loglevel") {
+	switch cfg.GetString("log.level") {
 	case "debug":
 		l.Level = logrus.DebugLevel
 	case "warning":
 		l.Level = logrus.WarnLevel
 	case "info":
 		l.Level = logrus.InfoLevel
+	case "error":
+		l.Level = logrus.ErrorLevel
 	default:
 		l.Level = logrus.DebugLevel
 	}
-	
+
 	return l
 }
 
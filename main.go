@@ -1,10 +1,9 @@
 package main
 
 import (
-	// "github.com/fhivemind/go-hastily/cmd"
-	"fmt"
+	"github.com/fhivemind/go-hastily/cmd"
 )
 
 func main() {
-	fmt.Printf("Hello")
+	cmd.Execute()
 }
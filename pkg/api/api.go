@@ -1,7 +1,9 @@
 package api
 
 import (
+	"encoding/json"
 	"errors"
+	"net/http"
 	"os"
 	"strconv"
 	"sync"
@@ -78,6 +80,58 @@ func (api *ApiModel) GetFiltered(modelFilter *Filter) ([]*Model, error) {
 	return filter(models, modelFilter), nil
 }
 
+// GetMeta fetches a single object from backend, keeping all its fields.
+// Returns nil if object does not exist.
+func (api *ApiModel) GetMeta(id int) (*Meta, error) {
+
+	// request form
+	request := Request{
+		Id: strconv.Itoa(id),
+	}
+
+	// do request
+	var object json.RawMessage
+	resp := api.Client.Get(request, &object)
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, nil
+	}
+	if !resp.Success {
+		return nil, errors.New(resp.Message)
+	}
+
+	// convert
+	meta, err := metaFromJSON(object)
+	if err != nil {
+		return nil, err
+	}
+	return &meta, nil
+}
+
+// GetSorted fetches objects from backend that satisfy a specific filter,
+// sorted by a field of the full objects. Sorting needs every object, so all
+// pages are fetched.
+func (api *ApiModel) GetSorted(modelFilter *Filter, field string) ([]*Model, error) {
+	if field == "" {
+		return api.GetFiltered(modelFilter)
+	}
+
+	// fetch raw objects of all pages to sort by any field
+	objects, err := api.GetPages(0)
+	if err != nil {
+		return nil, err
+	}
+	if err = SortObjects(objects, field); err != nil {
+		return nil, err
+	}
+
+	// decode and filter
+	models, err := decodeModels(objects)
+	if err != nil {
+		return nil, err
+	}
+	return filter(models, modelFilter), nil
+}
+
 // Create create provided object on backend.
 func (api *ApiModel) Create(model *Model) error {
 
@@ -96,6 +150,25 @@ func (api *ApiModel) Create(model *Model) error {
 	return nil
 }
 
+// CreateMeta creates object on backend from its full representation, keeping
+// fields which Model does not define.
+func (api *ApiModel) CreateMeta(meta *Meta) error {
+
+	// request form
+	request := Request{
+		Body: json.RawMessage(meta.Data),
+	}
+
+	// do request
+	resp := api.Client.Post(request, nil)
+	if !resp.Success {
+		return errors.New(resp.Message)
+	}
+
+	// success
+	return nil
+}
+
 // ListFilter filters objects that satisfy a specific filter.
 func (api *ApiModel) ListFilter(models []*Model, modelFilter *Filter) []*Model {
 	return filter(models, modelFilter)
@@ -281,6 +354,43 @@ func (api *ApiModel) UpdateMany(models []*Model, statuses *common.StatusList) *R
 	return resp
 }
 
+// UpdateMeta updates a specific object in the backend API from its full
+// representation, keeping fields which Model does not define.
+func (api *ApiModel) UpdateMeta(meta *Meta) Response {
+
+	// request form
+	request := Request{
+		Id:   strconv.Itoa(meta.Model.ID),
+		Body: json.RawMessage(meta.Data),
+	}
+
+	// do request
+	return api.Client.Put(request, nil)
+}
+
+// UpdateMetas updates multiple objects in the backend API from their full representation.
+func (api *ApiModel) UpdateMetas(metas []Meta) *ResponseList {
+
+	// async
+	var mutex sync.Mutex
+	var wg sync.WaitGroup
+
+	// perform http updates
+	resp := NewResponseList()
+	for i := range metas {
+		wg.Add(1)
+		go func(meta *Meta) {
+			defer wg.Done()
+			res := api.UpdateMeta(meta)
+			mutex.Lock()
+			defer mutex.Unlock()
+			resp.Insert(strconv.Itoa(meta.Model.ID), &res)
+		}(&metas[i])
+	}
+	wg.Wait()
+	return resp
+}
+
 // filter returns the list of objects which satisfy the filtering options.
 func filter(models []*Model, filter *Filter) (ret []*Model) {
 	// process data
@@ -0,0 +1,40 @@
+package api
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestCreateMetaKeepsFields(t *testing.T) {
+	var received []byte
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		received, _ = ioutil.ReadAll(req.Body)
+		w.Write([]byte("{}"))
+	}))
+	defer server.Close()
+	handler := ApiModel{
+		Client: &Client{Endpoint: server.URL, Model: "users", Instance: server.Client()},
+		Name:   "users",
+	}
+
+	metas, err := ParseMetas([]byte("id: 1\nname: a\nlabels:\n  team: b\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = handler.CreateMeta(&metas[0]); err != nil {
+		t.Fatal(err)
+	}
+
+	var got map[string]interface{}
+	if err = json.Unmarshal(received, &got); err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]interface{}{"id": 1.0, "name": "a", "labels": map[string]interface{}{"team": "b"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("created object = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,172 @@
+package api
+
+// This file stores whole collections in tar.gz archives holding
+// metadata.json and objects.jsonl, which can be restored later.
+
+import (
+	"archive/tar"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"time"
+)
+
+// BackupSchemaVersion is the version of backup archive layout.
+const BackupSchemaVersion = 1
+
+// Names of files inside backup archive.
+const (
+	backupMetadataFile = "metadata.json"
+	backupObjectsFile  = "objects.jsonl"
+)
+
+// BackupMetadata describes contents of backup archive.
+type BackupMetadata struct {
+	SchemaVersion int       `json:"schema_version"`
+	Model         string    `json:"model"`
+	Context       string    `json:"context"`
+	Endpoint      string    `json:"endpoint"`
+	CreatedAt     time.Time `json:"created_at"`
+	Count         int       `json:"count"`
+}
+
+// Backup holds all objects of a model with metadata.
+type Backup struct {
+	Metadata BackupMetadata
+	Objects  []json.RawMessage
+}
+
+// Backup fetches all objects of the model.
+func (api *ApiModel) Backup(pageSize int) (*Backup, error) {
+	objects, err := api.GetPages(pageSize)
+	if err != nil {
+		return nil, err
+	}
+	return &Backup{
+		Metadata: BackupMetadata{
+			SchemaVersion: BackupSchemaVersion,
+			Model:         api.Name,
+			Context:       envCfg.Context,
+			Endpoint:      envCfg.ApiEndpoint,
+			CreatedAt:     time.Now().UTC(),
+			Count:         len(objects),
+		},
+		Objects: objects,
+	}, nil
+}
+
+// Write writes backup as tar.gz archive.
+func (backup *Backup) Write(w io.Writer) error {
+	metadata, err := json.MarshalIndent(backup.Metadata, "", "  ")
+	if err != nil {
+		return err
+	}
+	var objects bytes.Buffer
+	for _, object := range backup.Objects {
+		if err = json.Compact(&objects, object); err != nil {
+			return err
+		}
+		objects.WriteByte('\n')
+	}
+
+	gz := gzip.NewWriter(w)
+	archive := tar.NewWriter(gz)
+	for _, file := range []struct {
+		name string
+		data []byte
+	}{
+		{backupMetadataFile, metadata},
+		{backupObjectsFile, objects.Bytes()},
+	} {
+		header := &tar.Header{
+			Name:    file.name,
+			Mode:    0600,
+			Size:    int64(len(file.data)),
+			ModTime: backup.Metadata.CreatedAt,
+		}
+		if err = archive.WriteHeader(header); err != nil {
+			return err
+		}
+		if _, err = archive.Write(file.data); err != nil {
+			return err
+		}
+	}
+	if err = archive.Close(); err != nil {
+		return err
+	}
+	return gz.Close()
+}
+
+// ReadBackup reads backup from tar.gz archive.
+func ReadBackup(r io.Reader) (*Backup, error) {
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, fmt.Errorf("Invalid backup archive: %v", err)
+	}
+	defer gz.Close()
+
+	backup := &Backup{}
+	hasMetadata := false
+	archive := tar.NewReader(gz)
+	for {
+		header, err := archive.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("Invalid backup archive: %v", err)
+		}
+		data, err := ioutil.ReadAll(archive)
+		if err != nil {
+			return nil, err
+		}
+
+		switch header.Name {
+		case backupMetadataFile:
+			if err = json.Unmarshal(data, &backup.Metadata); err != nil {
+				return nil, fmt.Errorf("Invalid backup metadata: %v", err)
+			}
+			hasMetadata = true
+		case backupObjectsFile:
+			scanner := bufio.NewScanner(bytes.NewReader(data))
+			scanner.Buffer(make([]byte, 64*1024), len(data)+1)
+			for scanner.Scan() {
+				if line := bytes.TrimSpace(scanner.Bytes()); len(line) > 0 {
+					backup.Objects = append(backup.Objects, json.RawMessage(append([]byte(nil), line...)))
+				}
+			}
+			if err = scanner.Err(); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	// verify
+	if !hasMetadata {
+		return nil, fmt.Errorf("Invalid backup archive: %s is missing.", backupMetadataFile)
+	}
+	if backup.Metadata.SchemaVersion > BackupSchemaVersion {
+		return nil, fmt.Errorf("Backup schema version %d is newer than supported version %d.", backup.Metadata.SchemaVersion, BackupSchemaVersion)
+	}
+	if backup.Metadata.Count != len(backup.Objects) {
+		return nil, fmt.Errorf("Backup is incomplete, expected %d objects but found %d.", backup.Metadata.Count, len(backup.Objects))
+	}
+	return backup, nil
+}
+
+// Metas converts backup objects to Meta objects.
+func (backup *Backup) Metas() ([]Meta, error) {
+	metas := make([]Meta, 0, len(backup.Objects))
+	for _, object := range backup.Objects {
+		meta, err := metaFromJSON(object)
+		if err != nil {
+			return nil, err
+		}
+		metas = append(metas, meta)
+	}
+	return metas, nil
+}
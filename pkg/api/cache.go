@@ -0,0 +1,312 @@
+package api
+
+// This file implements an on-disk HTTP cache for GET requests. Responses are
+// served from cache while fresh according to Cache-Control or Expires, and
+// revalidated with If-None-Match or If-Modified-Since otherwise. Mutating
+// requests invalidate cached responses of the affected collection.
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	cfg "github.com/fhivemind/go-hastily/config"
+	"github.com/fhivemind/go-hastily/pkg/auth"
+)
+
+// defaultCacheSizeMB limits cache size when nothing is configured.
+const defaultCacheSizeMB = 50
+
+// Cache stores GET responses on disk.
+type Cache struct {
+	Dir     string
+	MaxSize int64
+}
+
+// cacheEntry is a stored response.
+type cacheEntry struct {
+	URL      string      `json:"url"`
+	Status   int         `json:"status"`
+	Header   http.Header `json:"header"`
+	Body     []byte      `json:"body"`
+	StoredAt time.Time   `json:"stored_at"`
+}
+
+// NewCache creates cache configured for the current context, or returns
+// nil if caching is disabled.
+func NewCache() (*Cache, error) {
+	if envCfg.Cache.Disabled {
+		return nil, nil
+	}
+	dir, err := cacheDir()
+	if err != nil {
+		return nil, err
+	}
+	size := envCfg.Cache.MaxSizeMB
+	if size <= 0 {
+		size = defaultCacheSizeMB
+	}
+	return &Cache{Dir: dir, MaxSize: int64(size) << 20}, nil
+}
+
+// ClearCache removes all cached responses and returns number of removed entries.
+func ClearCache() (int, error) {
+	dir, err := cacheDir()
+	if err != nil {
+		return 0, err
+	}
+	files, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return 0, err
+	}
+	for _, file := range files {
+		if err := os.Remove(file); err != nil {
+			return 0, err
+		}
+	}
+	return len(files), nil
+}
+
+// cacheDir returns configured cache directory.
+func cacheDir() (string, error) {
+	if envCfg.Cache.Dir != "" {
+		return cfg.ExpandPath(envCfg.Cache.Dir)
+	}
+	dir, err := cfg.CacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "http"), nil
+}
+
+// Intercept serves GET requests from cache and invalidates cached
+// collections on successful mutations.
+func (cache *Cache) Intercept(next RoundTripFunc) RoundTripFunc {
+	return func(req *http.Request) (*http.Response, error) {
+		if req.Method != http.MethodGet {
+			resp, err := next(req)
+			if err == nil && resp.StatusCode < http.StatusBadRequest {
+				cache.invalidate(req)
+			}
+			return resp, err
+		}
+
+		// fresh or revalidated entry
+		path := cache.path(req)
+		entry, cached := cache.load(path)
+		if cached && entry.fresh() {
+			return entry.response(req), nil
+		}
+		if cached {
+			if etag := entry.Header.Get("ETag"); etag != "" {
+				req.Header.Set("If-None-Match", etag)
+			}
+			if modified := entry.Header.Get("Last-Modified"); modified != "" {
+				req.Header.Set("If-Modified-Since", modified)
+			}
+		}
+
+		resp, err := next(req)
+		if err != nil {
+			return resp, err
+		}
+
+		// unchanged
+		if cached && resp.StatusCode == http.StatusNotModified {
+			resp.Body.Close()
+			for key, values := range resp.Header {
+				entry.Header[key] = values
+			}
+			entry.StoredAt = time.Now()
+			cache.save(path, entry)
+			return entry.response(req), nil
+		}
+
+		// store new response
+		if resp.StatusCode == http.StatusOK && storable(resp.Header) {
+			cache.save(path, &cacheEntry{
+				URL:      req.URL.String(),
+				Status:   resp.StatusCode,
+				Header:   resp.Header,
+				Body:     responseBody(resp),
+				StoredAt: time.Now(),
+			})
+		}
+		return resp, nil
+	}
+}
+
+// path returns cache file of request. Files are prefixed by collection so
+// that mutations can invalidate them, and keyed by credentials so that
+// different users never share responses.
+func (cache *Cache) path(req *http.Request) string {
+	key := strings.Join([]string{
+		req.URL.String(),
+		req.Header.Get("Accept"),
+		credentialKey(req),
+	}, "\n")
+	return filepath.Join(cache.Dir, collectionKey(req)+"-"+hash(key)+".json")
+}
+
+// credentialKey identifies credentials of request. Signatures change on every
+// request, so signed requests are identified by context and signing key instead.
+func credentialKey(req *http.Request) string {
+	if auth.Mode() == auth.ModeHMAC {
+		return strings.Join([]string{auth.ModeHMAC, envCfg.Context, envCfg.Auth.Signing.KeyID}, "\n")
+	}
+	return req.Header.Get("Authorization") + "\n" + req.Header.Get(envCfg.Auth.Header)
+}
+
+// invalidate removes cached responses of request collection.
+func (cache *Cache) invalidate(req *http.Request) {
+	files, _ := filepath.Glob(filepath.Join(cache.Dir, collectionKey(req)+"-*.json"))
+	for _, file := range files {
+		os.Remove(file)
+	}
+}
+
+// load reads cache entry.
+func (cache *Cache) load(path string) (*cacheEntry, bool) {
+	byt, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, false
+	}
+	var entry cacheEntry
+	if err = json.Unmarshal(byt, &entry); err != nil || entry.Header == nil {
+		return nil, false
+	}
+	return &entry, true
+}
+
+// save writes cache entry and evicts the oldest entries above max size.
+// Failures only disable caching of the entry.
+func (cache *Cache) save(path string, entry *cacheEntry) {
+	byt, err := json.Marshal(entry)
+	if err != nil || int64(len(byt)) > cache.MaxSize {
+		return
+	}
+	if err = os.MkdirAll(cache.Dir, 0700); err != nil {
+		return
+	}
+	if err = ioutil.WriteFile(path, byt, 0600); err != nil {
+		return
+	}
+	cache.evict()
+}
+
+// evict removes least recently stored entries until cache fits max size.
+func (cache *Cache) evict() {
+	files, _ := filepath.Glob(filepath.Join(cache.Dir, "*.json"))
+	var infos []os.FileInfo
+	var total int64
+	for _, file := range files {
+		info, err := os.Stat(file)
+		if err != nil {
+			continue
+		}
+		infos = append(infos, info)
+		total += info.Size()
+	}
+	sort.Slice(infos, func(i, j int) bool {
+		return infos[i].ModTime().Before(infos[j].ModTime())
+	})
+	for _, info := range infos {
+		if total <= cache.MaxSize {
+			return
+		}
+		if os.Remove(filepath.Join(cache.Dir, info.Name())) == nil {
+			total -= info.Size()
+		}
+	}
+}
+
+// fresh checks if entry can be served without revalidation.
+func (entry *cacheEntry) fresh() bool {
+	maxAge, ok := maxAge(entry.Header)
+	return ok && time.Since(entry.StoredAt) < maxAge
+}
+
+// response creates response from cache entry.
+func (entry *cacheEntry) response(req *http.Request) *http.Response {
+	return &http.Response{
+		Status:        strconv.Itoa(entry.Status) + " " + http.StatusText(entry.Status),
+		StatusCode:    entry.Status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        entry.Header,
+		Body:          ioutil.NopCloser(bytes.NewReader(entry.Body)),
+		ContentLength: int64(len(entry.Body)),
+		Request:       req,
+	}
+}
+
+// storable checks if response may be cached. Responses without freshness
+// or validators would always be fetched again, so they are not stored.
+func storable(header http.Header) bool {
+	if cacheDirective(header, "no-store") {
+		return false
+	}
+	_, fresh := maxAge(header)
+	return fresh || header.Get("ETag") != "" || header.Get("Last-Modified") != ""
+}
+
+// maxAge returns freshness lifetime from Cache-Control or Expires.
+func maxAge(header http.Header) (time.Duration, bool) {
+	if cacheDirective(header, "no-cache") {
+		return 0, false
+	}
+	for _, directive := range strings.Split(header.Get("Cache-Control"), ",") {
+		directive = strings.TrimSpace(directive)
+		if strings.HasPrefix(directive, "max-age=") {
+			seconds, err := strconv.Atoi(strings.TrimPrefix(directive, "max-age="))
+			if err != nil {
+				return 0, false
+			}
+			return time.Duration(seconds) * time.Second, true
+		}
+	}
+	if expires, err := http.ParseTime(header.Get("Expires")); err == nil {
+		date, err := http.ParseTime(header.Get("Date"))
+		if err != nil {
+			date = time.Now()
+		}
+		return expires.Sub(date), true
+	}
+	return 0, false
+}
+
+// cacheDirective checks if Cache-Control header contains directive.
+func cacheDirective(header http.Header, name string) bool {
+	for _, directive := range strings.Split(header.Get("Cache-Control"), ",") {
+		if strings.EqualFold(strings.TrimSpace(directive), name) {
+			return true
+		}
+	}
+	return false
+}
+
+// collectionKey identifies collection of request, i.e. its path without
+// trailing object ID.
+func collectionKey(req *http.Request) string {
+	segments := strings.Split(strings.Trim(req.URL.Path, "/"), "/")
+	if _, err := strconv.Atoi(segments[len(segments)-1]); err == nil && len(segments) > 1 {
+		segments = segments[:len(segments)-1]
+	}
+	return hash(req.URL.Host + "/" + strings.Join(segments, "/"))
+}
+
+// hash returns short hex encoded SHA-256 of value.
+func hash(value string) string {
+	sum := sha256.Sum256([]byte(value))
+	return hex.EncodeToString(sum[:8])
+}
@@ -0,0 +1,47 @@
+package api
+
+import (
+	"net/http"
+	"reflect"
+	"testing"
+
+	"github.com/fhivemind/go-hastily/pkg/mock"
+)
+
+func TestCacheRevalidation(t *testing.T) {
+	ClearCache()
+	backend.Seed("cached", []mock.Object{{"id": 1}})
+	handler := NewAPI("cached")
+	if handler.Client.Cache == nil {
+		t.Fatal("cache is disabled")
+	}
+
+	fetch := func() []*Model {
+		models, err := handler.Get()
+		if err != nil {
+			t.Fatal(err)
+		}
+		return models
+	}
+	statuses.take()
+
+	// stored, then revalidated
+	first, second := fetch(), fetch()
+	if !reflect.DeepEqual(first, second) || len(second) != 1 {
+		t.Errorf("cached response %v, want %v", second, first)
+	}
+	if got, want := statuses.take(), []int{http.StatusOK, http.StatusNotModified}; !reflect.DeepEqual(got, want) {
+		t.Errorf("statuses = %v, want %v", got, want)
+	}
+
+	// mutation invalidates collection
+	if err := handler.Create(&Model{ID: 2}); err != nil {
+		t.Fatal(err)
+	}
+	if models := fetch(); len(models) != 2 {
+		t.Errorf("Get() after create = %v, want 2 objects", models)
+	}
+	if got, want := statuses.take(), []int{http.StatusOK, http.StatusOK}; !reflect.DeepEqual(got, want) {
+		t.Errorf("statuses = %v, want %v", got, want)
+	}
+}
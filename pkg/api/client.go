@@ -16,6 +16,7 @@ import (
 	"github.com/fhivemind/go-hastily/pkg/auth"
 	"github.com/fhivemind/go-hastily/pkg/common"
 	. "github.com/fhivemind/go-hastily/pkg/global"
+	"github.com/fhivemind/go-hastily/pkg/transport"
 )
 
 // config loads environment configuration
@@ -23,10 +24,14 @@ var envCfg = cfg.LoadConfig()
 
 // Client defines wrapper structure of http client.
 type Client struct {
-	Auth     *auth.Credentials
-	Endpoint string
-	Model    string
-	Instance *http.Client
+	Auth          *auth.Credentials
+	Authenticator auth.Authenticator
+	Endpoint      string
+	Model         string
+	Instance      *http.Client
+	DryRun        DryRunStrategy
+	Interceptors  []Interceptor
+	Cache         *Cache
 }
 
 // Response generalizes http request results.
@@ -75,28 +80,37 @@ type ApiClient interface {
 // to interact with API.
 func NewClient(model string) *Client {
 
+	// shared http client
+	instance, err := transport.Client()
+	HandleError(err)
+
 	// make default
 	client := Client{
-		Auth:     &auth.Credentials{},
-		Endpoint: envCfg.ApiEndpoint,
-		Instance: &http.Client{},
-		Model:    model,
+		Auth:         &auth.Credentials{},
+		Endpoint:     envCfg.ApiEndpoint,
+		Instance:     instance,
+		Model:        model,
+		Interceptors: registeredInterceptors(),
 	}
 
-	// check if auth needed
-	if envCfg.LoginEndpoint != "" {
+	// on-disk cache of GET responses
+	cache, err := NewCache()
+	HandleError(err)
+	client.Cache = cache
 
-		// load credentials
-		creds, err := auth.LoadCredentials()
-		HandleError(err)
+	// load authentication for context
+	authenticator, err := auth.NewAuthenticator()
+	HandleError(err)
+	client.Authenticator = authenticator
 
-		// update client
+	// verify login credentials
+	if creds, ok := authenticator.(*auth.Credentials); ok && auth.Mode() == auth.ModeLogin {
 		client.Auth = creds
-
-		// verify client
-		resp := client.CheckConnection()
-		if !resp.Success {
-			HandleError(errors.New(resp.Message))
+		if auth.LoginConfigured() && envCfg.VerifyEndpoint != "" {
+			resp := client.CheckConnection()
+			if !resp.Success {
+				HandleError(errors.New(resp.Message))
+			}
 		}
 	}
 
@@ -187,13 +201,11 @@ func (client *Client) request(request Request, object interface{}) Response {
 		return client.DefaultResponse("", err)
 	}
 
-	// set headers
-	req.Header.Set("Accept", "application/json")
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Authorization", fmt.Sprintf("bearer %s", client.Auth.AccessToken))
-
-	// send request
-	resp, err := client.Instance.Do(req)
+	// send through interceptors
+	resp, err := client.chain()(req)
+	if err == ErrDryRun {
+		return client.DefaultResponse("dry run", nil)
+	}
 	if err != nil {
 		return client.DefaultResponse("", err)
 	}
@@ -0,0 +1,89 @@
+package api
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/fhivemind/go-hastily/pkg/mock"
+)
+
+// rawObjects fetches all objects of model as decoded json.
+func rawObjects(t *testing.T, handler *ApiModel) []map[string]interface{} {
+	t.Helper()
+	var objects []map[string]interface{}
+	if resp := handler.Client.Get(Request{}, &objects); !resp.Success {
+		t.Fatalf("Get failed: %s", resp.Message)
+	}
+	return objects
+}
+
+func TestApiModelCRUD(t *testing.T) {
+	backend.Seed("crud", []mock.Object{{"id": 1, "name": "one"}, {"id": 2, "name": "two"}})
+	handler := NewAPI("crud")
+
+	// read
+	models, err := handler.Get()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(models) != 2 || models[0].ID != 1 || models[1].ID != 2 {
+		t.Fatalf("Get() = %v, want objects 1 and 2", models)
+	}
+	if filtered, _ := handler.GetFiltered(&Filter{ID: 2}); len(filtered) != 1 || filtered[0].ID != 2 {
+		t.Errorf("GetFiltered(2) = %v, want object 2", filtered)
+	}
+
+	// create and update keep fields which Model does not define
+	meta, err := NewMeta(map[string]interface{}{"id": 3, "name": "three", "labels": map[string]string{"team": "a"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = handler.CreateMeta(&meta); err != nil {
+		t.Fatal(err)
+	}
+	meta, _ = NewMeta(map[string]interface{}{"id": 1, "name": "uno"})
+	if resp := handler.UpdateMeta(&meta); !resp.Success {
+		t.Fatalf("UpdateMeta failed: %s", resp.Message)
+	}
+
+	// delete
+	if resp := handler.Delete(&Model{ID: 2}); !resp.Success {
+		t.Fatalf("Delete failed: %s", resp.Message)
+	}
+
+	want := []map[string]interface{}{
+		{"id": 1.0, "name": "uno"},
+		{"id": 3.0, "name": "three", "labels": map[string]interface{}{"team": "a"}},
+	}
+	if got := rawObjects(t, &handler); !reflect.DeepEqual(got, want) {
+		t.Errorf("objects = %v, want %v", got, want)
+	}
+}
+
+func TestApiModelDryRun(t *testing.T) {
+	backend.Seed("dryrun", []mock.Object{{"id": 1, "name": "one"}})
+
+	for _, strategy := range []DryRunStrategy{DryRunClient, DryRunServer} {
+		t.Run(string(strategy), func(t *testing.T) {
+			handler := NewAPI("dryrun")
+			handler.Client.DryRun = strategy
+
+			meta, _ := NewMeta(map[string]interface{}{"id": 2, "name": "two"})
+			if err := handler.CreateMeta(&meta); err != nil {
+				t.Fatal(err)
+			}
+			meta, _ = NewMeta(map[string]interface{}{"id": 1, "name": "uno"})
+			if resp := handler.UpdateMeta(&meta); !resp.Success {
+				t.Fatalf("UpdateMeta failed: %s", resp.Message)
+			}
+			if resp := handler.Delete(&Model{ID: 1}); !resp.Success {
+				t.Fatalf("Delete failed: %s", resp.Message)
+			}
+
+			want := []map[string]interface{}{{"id": 1.0, "name": "one"}}
+			if got := rawObjects(t, &handler); !reflect.DeepEqual(got, want) {
+				t.Errorf("objects = %v, want %v", got, want)
+			}
+		})
+	}
+}
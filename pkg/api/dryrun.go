@@ -0,0 +1,74 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	. "github.com/fhivemind/go-hastily/pkg/global"
+	"github.com/fhivemind/go-hastily/pkg/transport"
+)
+
+// DryRunStrategy defines how mutating requests are handled in dry-run mode.
+type DryRunStrategy string
+
+const (
+	// DryRunNone sends all requests to the backend.
+	DryRunNone DryRunStrategy = ""
+	// DryRunClient prints mutating requests instead of sending them.
+	DryRunClient DryRunStrategy = "client"
+	// DryRunServer sends mutating requests flagged as dry-run to the backend.
+	DryRunServer DryRunStrategy = "server"
+)
+
+// defaultDryRunParam is used for server dry-run when nothing is configured.
+const defaultDryRunParam = "dryRun"
+
+// ParseDryRun converts a flag value into DryRunStrategy.
+func ParseDryRun(value string) (DryRunStrategy, error) {
+	switch strategy := DryRunStrategy(strings.ToLower(value)); strategy {
+	case DryRunNone, DryRunClient, DryRunServer:
+		return strategy, nil
+	case "none":
+		return DryRunNone, nil
+	}
+	return DryRunNone, fmt.Errorf("Invalid dry-run value %q. Allowed values are client, server or none.", value)
+}
+
+// isMutating checks if request method changes state on backend.
+func isMutating(method string) bool {
+	return method != http.MethodGet && method != http.MethodHead && method != http.MethodOptions
+}
+
+// markServerDryRun flags request as dry-run using configured header or query param.
+func markServerDryRun(req *http.Request) {
+	if envCfg.DryRunHeader != "" {
+		req.Header.Set(envCfg.DryRunHeader, "true")
+		return
+	}
+	param := envCfg.DryRunParam
+	if param == "" {
+		param = defaultDryRunParam
+	}
+	query := req.URL.Query()
+	query.Set(param, "true")
+	req.URL.RawQuery = query.Encode()
+}
+
+// printDryRun prints request which would be sent to backend. Credentials
+// added by authentication, e.g. api key query param, are redacted.
+func printDryRun(req *http.Request, body []byte) {
+	var out strings.Builder
+	fmt.Fprintf(&out, "[dry-run] %s %s", req.Method, transport.RedactURL(req.URL))
+	if len(body) > 0 {
+		body = []byte(transport.RedactBody(body, req.Header.Get("Content-Type")))
+		var pretty bytes.Buffer
+		if json.Indent(&pretty, body, "    ", "  ") == nil {
+			body = pretty.Bytes()
+		}
+		fmt.Fprintf(&out, "\n    %s", body)
+	}
+	CLI.Info("%s", out.String())
+}
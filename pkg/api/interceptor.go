@@ -0,0 +1,147 @@
+package api
+
+// Interceptors wrap requests sent by Client, e.g. to add headers, request IDs,
+// signatures, logging, metrics or retries, without changing the client itself.
+
+import (
+	"bytes"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"sync"
+
+	"github.com/fhivemind/go-hastily/pkg/transport"
+)
+
+// RoundTripFunc sends a prepared request to backend.
+type RoundTripFunc func(req *http.Request) (*http.Response, error)
+
+// Interceptor wraps RoundTripFunc with additional behavior. It should call
+// next to continue the chain, or return without calling it to short-circuit.
+type Interceptor func(next RoundTripFunc) RoundTripFunc
+
+// ErrDryRun is returned by the end of the chain for requests which were only
+// printed in client dry-run mode. Interceptors should pass it through.
+var ErrDryRun = errors.New("Request not sent in dry-run mode.")
+
+var (
+	// interceptors are added to every client created afterwards.
+	interceptors     []Interceptor
+	interceptorMutex sync.Mutex
+)
+
+// RegisterInterceptor adds interceptor to all clients created afterwards.
+func RegisterInterceptor(interceptor Interceptor) {
+	interceptorMutex.Lock()
+	defer interceptorMutex.Unlock()
+	interceptors = append(interceptors, interceptor)
+}
+
+// registeredInterceptors returns a copy of globally registered interceptors.
+func registeredInterceptors() []Interceptor {
+	interceptorMutex.Lock()
+	defer interceptorMutex.Unlock()
+	return append([]Interceptor(nil), interceptors...)
+}
+
+// Use adds interceptors to client. They run in the order added, after
+// default headers are set. Authentication is applied after all of them, so
+// that signatures cover every change made to the request.
+func (client *Client) Use(interceptors ...Interceptor) {
+	client.Interceptors = append(client.Interceptors, interceptors...)
+}
+
+// chain composes built-in and client interceptors around send.
+func (client *Client) chain() RoundTripFunc {
+	all := []Interceptor{
+		StaticHeaders(map[string]string{
+			"Accept":       "application/json",
+			"Content-Type": "application/json",
+		}),
+		StaticHeaders(envCfg.Headers),
+	}
+	all = append(all, client.Interceptors...)
+	all = append(all, client.serverDryRun, client.authenticate)
+	if client.Cache != nil {
+		all = append(all, client.Cache.Intercept)
+	}
+	all = append(all, trace)
+
+	// first interceptor is the outermost
+	roundTrip := client.send
+	for i := len(all) - 1; i >= 0; i-- {
+		roundTrip = all[i](roundTrip)
+	}
+	return roundTrip
+}
+
+// send handles client dry-run and sends request with the shared http client.
+// Errors name the request URL, so credentials are redacted from it.
+func (client *Client) send(req *http.Request) (*http.Response, error) {
+	if client.DryRun == DryRunClient && isMutating(req.Method) {
+		printDryRun(req, requestBody(req))
+		return nil, ErrDryRun
+	}
+	resp, err := client.Instance.Do(req)
+	if urlErr, ok := err.(*url.Error); ok {
+		urlErr.URL = transport.RedactURL(req.URL)
+	}
+	return resp, err
+}
+
+// serverDryRun flags mutating requests in server dry-run mode.
+func (client *Client) serverDryRun(next RoundTripFunc) RoundTripFunc {
+	return func(req *http.Request) (*http.Response, error) {
+		if client.DryRun == DryRunServer && isMutating(req.Method) {
+			markServerDryRun(req)
+		}
+		return next(req)
+	}
+}
+
+// authenticate applies client credentials to request.
+func (client *Client) authenticate(next RoundTripFunc) RoundTripFunc {
+	return func(req *http.Request) (*http.Response, error) {
+		if client.Authenticator != nil {
+			if err := client.Authenticator.Apply(req); err != nil {
+				return nil, err
+			}
+		}
+		return next(req)
+	}
+}
+
+// StaticHeaders returns interceptor which sets headers on every request.
+func StaticHeaders(headers map[string]string) Interceptor {
+	return func(next RoundTripFunc) RoundTripFunc {
+		return func(req *http.Request) (*http.Response, error) {
+			for key, value := range headers {
+				req.Header.Set(key, value)
+			}
+			return next(req)
+		}
+	}
+}
+
+// requestBody returns a copy of request body without consuming it.
+func requestBody(req *http.Request) []byte {
+	if req.GetBody == nil {
+		return nil
+	}
+	body, err := req.GetBody()
+	if err != nil {
+		return nil
+	}
+	defer body.Close()
+	byt, _ := ioutil.ReadAll(body)
+	return byt
+}
+
+// responseBody reads response body and makes it readable again.
+func responseBody(resp *http.Response) []byte {
+	byt, _ := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	resp.Body = ioutil.NopCloser(bytes.NewReader(byt))
+	return byt
+}
@@ -0,0 +1,81 @@
+package api
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sync"
+	"testing"
+
+	cfg "github.com/fhivemind/go-hastily/config"
+	"github.com/fhivemind/go-hastily/pkg/mock"
+)
+
+// backend is the mock server shared by tests. Tests use their own models,
+// so that they do not see objects of each other.
+var backend = mock.NewServer(mock.Options{})
+
+// statuses records status codes returned by backend.
+var statuses = &statusRecorder{}
+
+// statusRecorder collects status codes of served requests.
+type statusRecorder struct {
+	mutex sync.Mutex
+	codes []int
+}
+
+// record wraps handler to remember status codes it writes.
+func (recorder *statusRecorder) record(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		writer := &statusWriter{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(writer, req)
+		recorder.mutex.Lock()
+		recorder.codes = append(recorder.codes, writer.status)
+		recorder.mutex.Unlock()
+	})
+}
+
+// take returns recorded status codes and resets recorder.
+func (recorder *statusRecorder) take() []int {
+	recorder.mutex.Lock()
+	defer recorder.mutex.Unlock()
+	codes := recorder.codes
+	recorder.codes = nil
+	return codes
+}
+
+// statusWriter remembers status code of response.
+type statusWriter struct {
+	http.ResponseWriter
+	status int
+}
+
+// WriteHeader records status code.
+func (writer *statusWriter) WriteHeader(status int) {
+	writer.status = status
+	writer.ResponseWriter.WriteHeader(status)
+}
+
+// TestMain isolates configuration, credentials and cache in temporary
+// directories and points the API endpoint at mock server.
+func TestMain(m *testing.M) {
+	dir, err := ioutil.TempDir("", "api")
+	if err != nil {
+		panic(err)
+	}
+	for _, name := range []string{"XDG_CONFIG_HOME", "XDG_CACHE_HOME", "XDG_DATA_HOME"} {
+		os.Setenv(name, dir+"/"+name)
+	}
+
+	server := httptest.NewServer(statuses.record(backend))
+	err = cfg.Reload(cfg.Options{Overrides: []string{"api=" + server.URL + "/api"}})
+	if err != nil {
+		panic(err)
+	}
+
+	code := m.Run()
+	server.Close()
+	os.RemoveAll(dir)
+	os.Exit(code)
+}
@@ -1,10 +1,14 @@
 package api
 
 import (
+	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io/ioutil"
 	"os"
+	"regexp"
+	"strings"
 
 	common "github.com/fhivemind/go-hastily/pkg/common"
 	. "github.com/fhivemind/go-hastily/pkg/global"
@@ -161,32 +165,202 @@ func (model *Model) ValidForFilter(filter *Filter) bool {
 	return true
 }
 
-// FromFile parses yaml file into Meta object.
+// FromFile parses yaml or json file into Meta object.
+// Use "-" to read from stdin.
 func (meta *Meta) FromFile(file string) error {
 
-	// read file
-	yml, err := ioutil.ReadFile(file)
+	// read all documents
+	metas, err := LoadMetas(file)
 	if err != nil {
 		return err
 	}
 
-	// convert yaml to json
-	byt, err := yaml.YAMLToJSON(yml)
+	// expect single object
+	if len(metas) != 1 {
+		return fmt.Errorf("Expected a single object in %s, found %d.", file, len(metas))
+	}
+
+	// update
+	*meta = metas[0]
+
+	return nil
+}
+
+// LoadMetas parses all objects from file into list of Meta objects.
+// Supported inputs are multi-document yaml, json arrays and
+// newline-delimited json. Use "-" to read from stdin.
+func LoadMetas(file string) ([]Meta, error) {
+
+	// read file
+	var (
+		data []byte
+		err  error
+	)
+	if file == "-" {
+		data, err = ioutil.ReadAll(os.Stdin)
+	} else {
+		data, err = ioutil.ReadFile(file)
+	}
 	if err != nil {
-		return err
+		return nil, err
+	}
+
+	metas, err := ParseMetas(data)
+	if err != nil {
+		return nil, err
+	}
+
+	// resolve ${...} references of string values
+	for i := range metas {
+		resolved, err := common.InterpolateJSON(metas[i].Data)
+		if err != nil {
+			return nil, err
+		}
+		if metas[i], err = metaFromJSON(resolved); err != nil {
+			return nil, err
+		}
+	}
+
+	return metas, nil
+}
+
+// ParseMetas parses all objects from yaml or json data into list of Meta objects.
+func ParseMetas(data []byte) ([]Meta, error) {
+
+	// split into json documents, or yaml documents if data is not json,
+	// e.g. yaml flow style starting with { or [
+	docs, err := splitJSON(data)
+	if err != nil {
+		docs = nil
+		for _, yml := range yamlSeparator.Split(string(data), -1) {
+			doc, err := yaml.YAMLToJSON([]byte(yml))
+			if err != nil {
+				return nil, err
+			}
+			docs = append(docs, doc)
+		}
+	}
+
+	// extract to Meta objects
+	var metas []Meta
+	for _, doc := range docs {
+		doc = bytes.TrimSpace(doc)
+		switch {
+		case len(doc) == 0 || bytes.Equal(doc, []byte("null")):
+			// skip empty documents
+		case doc[0] == '[':
+			var items []json.RawMessage
+			if err := json.Unmarshal(doc, &items); err != nil {
+				return nil, err
+			}
+			for _, item := range items {
+				meta, err := metaFromJSON(item)
+				if err != nil {
+					return nil, err
+				}
+				metas = append(metas, meta)
+			}
+		default:
+			meta, err := metaFromJSON(doc)
+			if err != nil {
+				return nil, err
+			}
+			metas = append(metas, meta)
+		}
+	}
+
+	return metas, nil
+}
+
+// splitJSON splits json objects, arrays or newline-delimited json into documents.
+func splitJSON(data []byte) ([]json.RawMessage, error) {
+	trimmed := bytes.TrimSpace(data)
+	if len(trimmed) == 0 || (trimmed[0] != '{' && trimmed[0] != '[') {
+		return nil, errors.New("Data is not JSON.")
+	}
+	var docs []json.RawMessage
+	decoder := json.NewDecoder(bytes.NewReader(trimmed))
+	for decoder.More() {
+		var doc json.RawMessage
+		if err := decoder.Decode(&doc); err != nil {
+			return nil, err
+		}
+		docs = append(docs, doc)
 	}
+	return docs, nil
+}
 
-	// extract to Model
+// yamlSeparator matches yaml document separators.
+var yamlSeparator = regexp.MustCompile(`(?m)^---[ \t]*(#.*)?$`)
+
+// metaFromJSON creates Meta object from json object.
+func metaFromJSON(data []byte) (Meta, error) {
 	var model Model
-	err = yaml.Unmarshal(yml, &model)
+	if err := json.Unmarshal(data, &model); err != nil {
+		return Meta{}, err
+	}
+	return Meta{
+		Model: model,
+		Data:  []byte(data),
+	}, nil
+}
+
+// NewMeta creates Meta object from any value which encodes to a json object.
+func NewMeta(object interface{}) (Meta, error) {
+	byt, err := json.Marshal(object)
+	if err != nil {
+		return Meta{}, err
+	}
+	return metaFromJSON(byt)
+}
+
+// Set overrides a dot-separated key of Meta object, e.g. "address.city".
+// Value is parsed as yaml scalar, so numbers and booleans keep their type.
+func (meta *Meta) Set(key string, value string) error {
+
+	// parse value
+	var parsed interface{}
+	if err := yaml.Unmarshal([]byte(value), &parsed); err != nil {
+		parsed = value
+	}
+
+	// update nested key
+	var object map[string]interface{}
+	if err := json.Unmarshal(meta.Data, &object); err != nil {
+		return err
+	}
+	if object == nil {
+		object = make(map[string]interface{})
+	}
+	setField(object, key, parsed)
+
+	// update meta
+	byt, err := json.Marshal(object)
 	if err != nil {
 		return err
 	}
+	updated, err := metaFromJSON(byt)
+	if err != nil {
+		return err
+	}
+	*meta = updated
 
-	// update
-	meta.Model = model
-	meta.Data = byt
+	return nil
+}
 
+// ApplyOverrides sets "key=value" overrides on all Meta objects.
+func ApplyOverrides(metas []Meta, overrides []string) error {
+	for _, override := range overrides {
+		parts := strings.SplitN(override, "=", 2)
+		if len(parts) != 2 || parts[0] == "" {
+			return fmt.Errorf("Invalid override %q, expected key=value.", override)
+		}
+		for i := range metas {
+			if err := metas[i].Set(parts[0], parts[1]); err != nil {
+				return err
+			}
+		}
+	}
 	return nil
 }
 
@@ -0,0 +1,53 @@
+package api
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseMetas(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		want    []int
+		wantErr bool
+	}{
+		{"json object", `{"id": 1, "name": "a"}`, []int{1}, false},
+		{"json array", `[{"id": 1}, {"id": 2}]`, []int{1, 2}, false},
+		{"newline-delimited json", "{\"id\": 1}\n{\"id\": 2}\n", []int{1, 2}, false},
+		{"yaml", "id: 1\nname: a\n", []int{1}, false},
+		{"multi-document yaml", "id: 1\n---\nid: 2\n--- # last\nid: 3\n", []int{1, 2, 3}, false},
+		{"yaml list", "- id: 1\n- id: 2\n", []int{1, 2}, false},
+		{"yaml flow object", "{id: 1, name: a}", []int{1}, false},
+		{"yaml flow list", "[{id: 1}, {id: 2}]", []int{1, 2}, false},
+		{"empty documents", "---\nid: 1\n---\n", []int{1}, false},
+		{"empty", "", nil, false},
+		{"invalid yaml", "id: [1", nil, true},
+		{"invalid id", `{"id": "one"}`, nil, true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			metas, err := ParseMetas([]byte(test.input))
+			if (err != nil) != test.wantErr {
+				t.Fatalf("ParseMetas(%q) error = %v, want error %v", test.input, err, test.wantErr)
+			}
+			var ids []int
+			for _, meta := range metas {
+				ids = append(ids, meta.Model.ID)
+			}
+			if !reflect.DeepEqual(ids, test.want) {
+				t.Errorf("ParseMetas(%q) ids = %v, want %v", test.input, ids, test.want)
+			}
+		})
+	}
+}
+
+func TestParseMetasKeepsFields(t *testing.T) {
+	metas, err := ParseMetas([]byte("id: 1\nlabels:\n  team: a\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `{"id":1,"labels":{"team":"a"}}`; len(metas) != 1 || string(metas[0].Data) != want {
+		t.Errorf("ParseMetas data = %s, want %s", metas[0].Data, want)
+	}
+}
@@ -0,0 +1,90 @@
+package api
+
+// This file pages through whole collections using configurable query params.
+
+import (
+	"encoding/json"
+	"errors"
+	"strconv"
+)
+
+// Defaults used when pagination is not configured.
+const (
+	defaultPageParam = "page"
+	defaultSizeParam = "per_page"
+	defaultPageSize  = 100
+)
+
+// GetPages fetches all objects page by page, keeping every field. Paging
+// stops on a short page, or when backend ignores pagination and repeats objects.
+func (api *ApiModel) GetPages(size int) ([]json.RawMessage, error) {
+	settings := envCfg.Pagination
+	pageParam, sizeParam := settings.PageParam, settings.SizeParam
+	if pageParam == "" {
+		pageParam = defaultPageParam
+	}
+	if sizeParam == "" {
+		sizeParam = defaultSizeParam
+	}
+	if size <= 0 {
+		size = settings.PageSize
+	}
+	if size <= 0 {
+		size = defaultPageSize
+	}
+
+	var all []json.RawMessage
+	seen := make(map[int]bool)
+	for page := 1; ; page++ {
+		request := Request{
+			Query: map[string]string{
+				pageParam: strconv.Itoa(page),
+				sizeParam: strconv.Itoa(size),
+			},
+		}
+		var objects []json.RawMessage
+		resp := api.Client.Get(request, &objects)
+		if !resp.Success {
+			return nil, errors.New(resp.Message)
+		}
+
+		added := 0
+		for _, object := range objects {
+			var model Model
+			if err := json.Unmarshal(object, &model); err != nil {
+				return nil, err
+			}
+			if model.ID != 0 && seen[model.ID] {
+				continue
+			}
+			seen[model.ID] = true
+			all = append(all, object)
+			added++
+		}
+		if len(objects) < size || len(objects) > size || added == 0 {
+			return all, nil
+		}
+	}
+}
+
+// GetAll fetches all objects from backend page by page.
+func (api *ApiModel) GetAll() ([]*Model, error) {
+	objects, err := api.GetPages(0)
+	if err != nil {
+		return nil, err
+	}
+	return decodeModels(objects)
+}
+
+// decodeModels converts raw objects into models.
+func decodeModels(objects []json.RawMessage) ([]*Model, error) {
+	models := make([]*Model, 0, len(objects))
+	for _, object := range objects {
+		var model Model
+		if err := json.Unmarshal(object, &model); err != nil {
+			return nil, err
+		}
+		models = append(models, &model)
+	}
+	return models, nil
+}
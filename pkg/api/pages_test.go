@@ -0,0 +1,76 @@
+package api
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/fhivemind/go-hastily/pkg/mock"
+)
+
+func TestGetPages(t *testing.T) {
+	var objects []mock.Object
+	for i := 1; i <= 7; i++ {
+		objects = append(objects, mock.Object{"id": i, "extra": i * 10})
+	}
+	backend.Seed("pages", objects)
+	handler := NewAPI("pages")
+
+	tests := []struct {
+		name     string
+		size     int
+		requests int
+	}{
+		{"short last page", 3, 3},
+		{"empty last page", 7, 2},
+		{"single page", 100, 1},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ClearCache()
+			statuses.take()
+
+			pages, err := handler.GetPages(test.size)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if requests := len(statuses.take()); requests != test.requests {
+				t.Errorf("GetPages(%d) sent %d requests, want %d", test.size, requests, test.requests)
+			}
+			if len(pages) != 7 {
+				t.Fatalf("GetPages(%d) returned %d objects, want 7", test.size, len(pages))
+			}
+			for i, page := range pages {
+				var object map[string]int
+				if err := json.Unmarshal(page, &object); err != nil {
+					t.Fatal(err)
+				}
+				if object["id"] != i+1 || object["extra"] != (i+1)*10 {
+					t.Errorf("object %d = %s, want id %d with extra field", i, page, i+1)
+				}
+			}
+		})
+	}
+}
+
+func TestGetSortedFetchesAllPages(t *testing.T) {
+	backend.Seed("sorted", []mock.Object{
+		{"id": 1, "name": "d"}, {"id": 2, "name": "b"}, {"id": 3, "name": "e"},
+		{"id": 4, "name": "a"}, {"id": 5, "name": "c"},
+	})
+	handler := NewAPI("sorted")
+	defer func(size int) { envCfg.Pagination.PageSize = size }(envCfg.Pagination.PageSize)
+	envCfg.Pagination.PageSize = 2
+
+	models, err := handler.GetSorted(nil, "-name")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var ids []int
+	for _, model := range models {
+		ids = append(ids, model.ID)
+	}
+	if want := []int{3, 1, 5, 2, 4}; !reflect.DeepEqual(ids, want) {
+		t.Errorf("GetSorted(-name) ids = %v, want %v", ids, want)
+	}
+}
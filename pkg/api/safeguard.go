@@ -0,0 +1,107 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// SafeguardError reports safeguard policies violated by a bulk operation.
+type SafeguardError struct {
+	Action     string
+	Count      int
+	Violations []string
+}
+
+// Error formats violations as a readable report.
+func (err *SafeguardError) Error() string {
+	return fmt.Sprintf("Safeguards blocked %s %d objects:\n  - %s",
+		err.Action, err.Count, strings.Join(err.Violations, "\n  - "))
+}
+
+// CheckSafeguards verifies that a bulk operation on models satisfies configured
+// safeguard policies. Total is the size of the whole collection and is used for
+// percentage limits. Force allows operations in forbidden contexts.
+func (api *ApiModel) CheckSafeguards(action string, models []*Model, total int, force bool) error {
+	guards := envCfg.Safeguards
+	var violations []string
+
+	// size limits
+	if guards.MaxObjects > 0 && len(models) > guards.MaxObjects {
+		violations = append(violations, fmt.Sprintf("%d objects exceed the limit of %d per operation", len(models), guards.MaxObjects))
+	}
+	if guards.MaxPercent > 0 && total > 0 {
+		percent := float64(len(models)) * 100 / float64(total)
+		if percent > guards.MaxPercent {
+			violations = append(violations, fmt.Sprintf("%.1f%% of the collection exceeds the limit of %.1f%%", percent, guards.MaxPercent))
+		}
+	}
+
+	// forbidden contexts
+	for _, ctx := range guards.ForbiddenContexts {
+		if ctx == envCfg.Context && !force {
+			violations = append(violations, fmt.Sprintf("context %q is protected, pass --i-mean-it to proceed", ctx))
+		}
+	}
+
+	// protected objects
+	labels, err := api.objectLabels()
+	if err != nil {
+		return err
+	}
+	for _, model := range models {
+		if reason := protectedReason(model, labels[model.ID]); reason != "" {
+			violations = append(violations, fmt.Sprintf("object %d is protected by %s", model.ID, reason))
+		}
+	}
+
+	if len(violations) == 0 {
+		return nil
+	}
+	return &SafeguardError{
+		Action:     action,
+		Count:      len(models),
+		Violations: violations,
+	}
+}
+
+// protectedReason returns the policy protecting model with given labels, if any.
+func protectedReason(model *Model, labels map[string]interface{}) string {
+	guards := envCfg.Safeguards
+	for _, id := range guards.ProtectedIDs {
+		if model.ID == id {
+			return "ID"
+		}
+	}
+	for key, value := range guards.ProtectedLabels {
+		if label, ok := labels[key]; ok && fmt.Sprintf("%v", label) == value {
+			return fmt.Sprintf("label %s=%s", key, value)
+		}
+	}
+	return ""
+}
+
+// objectLabels reads labels of all objects on backend by their ID. Model
+// does not hold labels, so they are read from full objects, and only when
+// protected labels are configured.
+func (api *ApiModel) objectLabels() (map[int]map[string]interface{}, error) {
+	if len(envCfg.Safeguards.ProtectedLabels) == 0 {
+		return nil, nil
+	}
+	objects, err := api.GetPages(0)
+	if err != nil {
+		return nil, fmt.Errorf("Unable to read labels of protected objects: %v", err)
+	}
+	labels := make(map[int]map[string]interface{}, len(objects))
+	for _, raw := range objects {
+		var object struct {
+			ID     int                    `json:"id"`
+			Labels map[string]interface{} `json:"labels"`
+		}
+		if err := json.Unmarshal(raw, &object); err != nil {
+			return nil, err
+		}
+		labels[object.ID] = object.Labels
+	}
+	return labels, nil
+}
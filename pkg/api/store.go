@@ -0,0 +1,220 @@
+package api
+
+// This file keeps local snapshots of models, one JSON-lines file per model
+// and context, so that objects can be queried offline.
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	cfg "github.com/fhivemind/go-hastily/config"
+)
+
+// Snapshot holds objects of a model as last synced from backend.
+type Snapshot struct {
+	Model    string
+	Path     string
+	SyncedAt time.Time
+	Objects  []json.RawMessage
+}
+
+// SnapshotPath returns file of model snapshot in the current context.
+func SnapshotPath(model string) (string, error) {
+	dir, err := cfg.DataDir()
+	if err != nil {
+		return "", err
+	}
+	context := envCfg.Context
+	if context == "" {
+		context = "default"
+	}
+	return filepath.Join(dir, "store", context, model+".jsonl"), nil
+}
+
+// Sync fetches all objects of the model page by page and replaces its local snapshot.
+func (api *ApiModel) Sync() (*Snapshot, error) {
+
+	// fetch raw objects to keep every field
+	objects, err := api.GetPages(0)
+	if err != nil {
+		return nil, err
+	}
+
+	// write atomically, one object per line
+	path, err := SnapshotPath(api.Name)
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	for _, object := range objects {
+		if err = json.Compact(&buf, object); err != nil {
+			return nil, err
+		}
+		buf.WriteByte('\n')
+	}
+	if err = os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return nil, err
+	}
+	tmp, err := ioutil.TempFile(filepath.Dir(path), ".snapshot-*")
+	if err != nil {
+		return nil, err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err = tmp.Write(buf.Bytes()); err != nil {
+		tmp.Close()
+		return nil, err
+	}
+	if err = tmp.Close(); err != nil {
+		return nil, err
+	}
+	if err = os.Rename(tmp.Name(), path); err != nil {
+		return nil, err
+	}
+
+	return &Snapshot{Model: api.Name, Path: path, SyncedAt: time.Now(), Objects: objects}, nil
+}
+
+// LoadSnapshot reads local snapshot of the model.
+func (api *ApiModel) LoadSnapshot() (*Snapshot, error) {
+	path, err := SnapshotPath(api.Name)
+	if err != nil {
+		return nil, err
+	}
+	file, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil, fmt.Errorf("No local snapshot of %s, run sync %s first.", api.Name, api.Name)
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	info, err := file.Stat()
+	if err != nil {
+		return nil, err
+	}
+
+	snapshot := &Snapshot{Model: api.Name, Path: path, SyncedAt: info.ModTime()}
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 64*1024*1024)
+	for scanner.Scan() {
+		if line := bytes.TrimSpace(scanner.Bytes()); len(line) > 0 {
+			snapshot.Objects = append(snapshot.Objects, json.RawMessage(append([]byte(nil), line...)))
+		}
+	}
+	return snapshot, scanner.Err()
+}
+
+// GetOffline returns objects from local snapshot that satisfy a specific filter,
+// sorted by a field of the full objects.
+func (api *ApiModel) GetOffline(modelFilter *Filter, field string) ([]*Model, error) {
+	snapshot, err := api.LoadSnapshot()
+	if err != nil {
+		return nil, err
+	}
+	if err = SortObjects(snapshot.Objects, field); err != nil {
+		return nil, err
+	}
+	models, err := snapshot.Models()
+	if err != nil {
+		return nil, err
+	}
+	return filter(models, modelFilter), nil
+}
+
+// Models decodes snapshot objects.
+func (snapshot *Snapshot) Models() ([]*Model, error) {
+	models := make([]*Model, 0, len(snapshot.Objects))
+	for i, object := range snapshot.Objects {
+		var model Model
+		if err := json.Unmarshal(object, &model); err != nil {
+			return nil, fmt.Errorf("Invalid object on line %d of %s: %v", i+1, snapshot.Path, err)
+		}
+		models = append(models, &model)
+	}
+	return models, nil
+}
+
+// SortObjects sorts raw objects by a dot-separated field, e.g. "address.city".
+// Prefix the field with "-" to sort in descending order. Objects without the
+// field are placed last.
+func SortObjects(objects []json.RawMessage, field string) error {
+	if field == "" {
+		return nil
+	}
+	descending := field[0] == '-'
+	if descending {
+		field = field[1:]
+	}
+
+	// read values
+	values := make([]interface{}, len(objects))
+	found := make([]bool, len(objects))
+	known := false
+	for i, raw := range objects {
+		var object map[string]interface{}
+		if err := json.Unmarshal(raw, &object); err != nil {
+			return err
+		}
+		values[i], found[i] = lookupField(object, field)
+		known = known || found[i]
+	}
+	if !known && len(objects) > 0 {
+		return fmt.Errorf("Unknown sort field %q.", field)
+	}
+
+	// sort indexes, then reorder objects
+	order := make([]int, len(objects))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(i, j int) bool {
+		a, b := order[i], order[j]
+		if found[a] != found[b] {
+			return found[a]
+		}
+		if descending {
+			return lessValue(values[b], values[a])
+		}
+		return lessValue(values[a], values[b])
+	})
+	sorted := make([]json.RawMessage, len(objects))
+	for i, index := range order {
+		sorted[i] = objects[index]
+	}
+	copy(objects, sorted)
+	return nil
+}
+
+// lessValue compares decoded JSON values, numbers numerically and
+// everything else as text.
+func lessValue(a interface{}, b interface{}) bool {
+	if x, ok := a.(float64); ok {
+		if y, ok := b.(float64); ok {
+			return x < y
+		}
+	}
+	return fmt.Sprintf("%v", a) < fmt.Sprintf("%v", b)
+}
+
+// lookupField returns value of a dot-separated field.
+func lookupField(object map[string]interface{}, field string) (interface{}, bool) {
+	parts := strings.Split(field, ".")
+	current := object
+	for _, part := range parts[:len(parts)-1] {
+		next, ok := current[part].(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+		current = next
+	}
+	value, ok := current[parts[len(parts)-1]]
+	return value, ok
+}
@@ -0,0 +1,96 @@
+package api
+
+// This file traces requests and responses when verbose output is requested.
+// Credentials are redacted by the transport package before anything is logged.
+
+import (
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/fhivemind/go-hastily/log"
+	"github.com/fhivemind/go-hastily/pkg/transport"
+)
+
+// Tracing levels as set with repeated -v flags.
+const (
+	TraceRequests = 1
+	TraceHeaders  = 2
+	TraceBodies   = 3
+)
+
+// trace is interceptor which logs requests according to verbosity.
+func trace(next RoundTripFunc) RoundTripFunc {
+	return func(req *http.Request) (*http.Response, error) {
+		if log.Verbosity() < TraceRequests {
+			return next(req)
+		}
+
+		start := time.Now()
+		resp, err := next(req)
+		if err == ErrDryRun {
+			return resp, err
+		}
+		var reqBody, respBody []byte
+		if log.Verbosity() >= TraceBodies {
+			reqBody = requestBody(req)
+			if err == nil {
+				respBody = responseBody(resp)
+			}
+		}
+		traceRequest(req, reqBody, resp, respBody, time.Since(start), err)
+		return resp, err
+	}
+}
+
+// traceRequest logs request and response according to verbosity.
+func traceRequest(req *http.Request, reqBody []byte, resp *http.Response, respBody []byte, latency time.Duration, err error) {
+	level := log.Verbosity()
+	if level < TraceRequests {
+		return
+	}
+
+	// request line
+	fields := log.Fields{
+		"method":  req.Method,
+		"url":     transport.RedactURL(req.URL),
+		"latency": latency.Round(time.Millisecond).String(),
+	}
+	if err != nil {
+		log.WithFields(fields.With("error", err.Error())).Debug("request failed")
+		return
+	}
+	fields.With("status", resp.StatusCode)
+
+	// details
+	if level >= TraceHeaders {
+		fields.With("request_headers", formatHeaders(req.Header))
+		fields.With("response_headers", formatHeaders(resp.Header))
+	}
+	if level >= TraceBodies {
+		if len(reqBody) > 0 {
+			fields.With("request_body", transport.RedactBody(reqBody, req.Header.Get("Content-Type")))
+		}
+		if len(respBody) > 0 {
+			fields.With("response_body", transport.RedactBody(respBody, resp.Header.Get("Content-Type")))
+		}
+	}
+	log.WithFields(fields).Debug("request")
+}
+
+// formatHeaders formats headers with sensitive values hidden.
+func formatHeaders(header http.Header) string {
+	redacted := transport.RedactHeader(header)
+	var keys []string
+	for key := range redacted {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var lines []string
+	for _, key := range keys {
+		lines = append(lines, key+": "+strings.Join(redacted[key], ", "))
+	}
+	return strings.Join(lines, "; ")
+}
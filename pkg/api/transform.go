@@ -0,0 +1,222 @@
+package api
+
+// This file selects and transforms raw objects, e.g. when copying them
+// between contexts.
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"sort"
+	"strings"
+
+	common "github.com/fhivemind/go-hastily/pkg/common"
+	"github.com/ghodss/yaml"
+	"github.com/r3labs/diff/v2"
+)
+
+// DefaultStripFields are server-managed fields removed from copied objects.
+var DefaultStripFields = []string{"created_at", "updated_at", "createdAt", "updatedAt", "etag", "_links"}
+
+// Condition matches a dot-separated field against a value.
+type Condition struct {
+	Field  string
+	Value  string
+	Negate bool
+}
+
+// Where holds conditions which must all match.
+type Where []Condition
+
+// Mapping describes field transformations, applied in order: delete,
+// rename, replace and set.
+type Mapping struct {
+	Delete  []string                     `json:"delete"`
+	Rename  map[string]string            `json:"rename"`
+	Replace map[string]map[string]string `json:"replace"`
+	Set     map[string]interface{}       `json:"set"`
+}
+
+// ParseWhere parses conditions as field=value or field!=value.
+func ParseWhere(expressions []string) (Where, error) {
+	var where Where
+	for _, expression := range expressions {
+		condition := Condition{}
+		parts := strings.SplitN(expression, "!=", 2)
+		if len(parts) == 2 {
+			condition.Negate = true
+		} else {
+			parts = strings.SplitN(expression, "=", 2)
+		}
+		if len(parts) != 2 || strings.TrimSpace(parts[0]) == "" {
+			return nil, fmt.Errorf("Invalid condition %q, expected field=value or field!=value.", expression)
+		}
+		condition.Field, condition.Value = strings.TrimSpace(parts[0]), strings.TrimSpace(parts[1])
+		where = append(where, condition)
+	}
+	return where, nil
+}
+
+// Match checks if object satisfies all conditions. Values are compared as text.
+func (where Where) Match(object map[string]interface{}) bool {
+	for _, condition := range where {
+		value, ok := lookupField(object, condition.Field)
+		equal := ok && fmt.Sprintf("%v", value) == condition.Value
+		if equal == condition.Negate {
+			return false
+		}
+	}
+	return true
+}
+
+// LoadMapping reads mapping from YAML or JSON file. References like
+// ${VAR} in string values are interpolated.
+func LoadMapping(file string) (*Mapping, error) {
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	if data, err = yaml.YAMLToJSON(data); err != nil {
+		return nil, fmt.Errorf("Invalid mapping file %s: %v", file, err)
+	}
+	if data, err = common.InterpolateJSON(data); err != nil {
+		return nil, err
+	}
+	var mapping Mapping
+	if err = json.Unmarshal(data, &mapping); err != nil {
+		return nil, fmt.Errorf("Invalid mapping file %s: %v", file, err)
+	}
+	return &mapping, nil
+}
+
+// Apply transforms object in place. Renames happen at once, so chained
+// renames like a->b and b->c move each value only once. Fields are processed
+// in sorted order and longer replacements win, so results never depend on
+// map iteration order.
+func (mapping *Mapping) Apply(object map[string]interface{}) {
+	for _, field := range mapping.Delete {
+		deleteField(object, field)
+	}
+
+	// rename
+	type move struct {
+		to    string
+		value interface{}
+	}
+	var moves []move
+	renames := sortedKeys(mapping.Rename)
+	for _, from := range renames {
+		if value, ok := lookupField(object, from); ok {
+			moves = append(moves, move{mapping.Rename[from], value})
+		}
+	}
+	for _, from := range renames {
+		deleteField(object, from)
+	}
+	for _, move := range moves {
+		setField(object, move.to, move.value)
+	}
+
+	// replace
+	fields := make([]string, 0, len(mapping.Replace))
+	for field := range mapping.Replace {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+	for _, field := range fields {
+		value, ok := lookupField(object, field)
+		text, isText := value.(string)
+		if !ok || !isText {
+			continue
+		}
+		olds := sortedKeys(mapping.Replace[field])
+		sort.SliceStable(olds, func(i, j int) bool {
+			return len(olds[i]) > len(olds[j])
+		})
+		var pairs []string
+		for _, old := range olds {
+			pairs = append(pairs, old, mapping.Replace[field][old])
+		}
+		setField(object, field, strings.NewReplacer(pairs...).Replace(text))
+	}
+
+	// set
+	fields = fields[:0]
+	for field := range mapping.Set {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+	for _, field := range fields {
+		setField(object, field, mapping.Set[field])
+	}
+}
+
+// sortedKeys returns keys of map in sorted order.
+func sortedKeys(values map[string]string) []string {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// StripFields removes dot-separated fields from object.
+func StripFields(object map[string]interface{}, fields []string) {
+	for _, field := range fields {
+		deleteField(object, field)
+	}
+}
+
+// DiffObjects lists changes needed to turn current object into desired one,
+// one "field: old -> new" line per change, sorted by field.
+func DiffObjects(current map[string]interface{}, desired map[string]interface{}) ([]string, error) {
+	changes, err := diff.Diff(current, desired)
+	if err != nil {
+		return nil, err
+	}
+	var lines []string
+	for _, change := range changes {
+		switch change.Type {
+		case diff.CREATE:
+			lines = append(lines, fmt.Sprintf("+ %s: %v", strings.Join(change.Path, "."), change.To))
+		case diff.DELETE:
+			lines = append(lines, fmt.Sprintf("- %s: %v", strings.Join(change.Path, "."), change.From))
+		default:
+			lines = append(lines, fmt.Sprintf("~ %s: %v -> %v", strings.Join(change.Path, "."), change.From, change.To))
+		}
+	}
+	sort.Slice(lines, func(i, j int) bool {
+		return lines[i][2:] < lines[j][2:]
+	})
+	return lines, nil
+}
+
+// setField sets a dot-separated field, creating parent objects as needed.
+func setField(object map[string]interface{}, field string, value interface{}) {
+	parts := strings.Split(field, ".")
+	current := object
+	for _, part := range parts[:len(parts)-1] {
+		next, ok := current[part].(map[string]interface{})
+		if !ok {
+			next = make(map[string]interface{})
+			current[part] = next
+		}
+		current = next
+	}
+	current[parts[len(parts)-1]] = value
+}
+
+// deleteField removes a dot-separated field.
+func deleteField(object map[string]interface{}, field string) {
+	parts := strings.Split(field, ".")
+	current := object
+	for _, part := range parts[:len(parts)-1] {
+		next, ok := current[part].(map[string]interface{})
+		if !ok {
+			return
+		}
+		current = next
+	}
+	delete(current, parts[len(parts)-1])
+}
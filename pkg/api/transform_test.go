@@ -0,0 +1,93 @@
+package api
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestMappingApply(t *testing.T) {
+	tests := []struct {
+		name    string
+		mapping Mapping
+		input   string
+		want    string
+	}{
+		{
+			"delete",
+			Mapping{Delete: []string{"id", "meta.etag", "missing.field"}},
+			`{"id": 1, "name": "a", "meta": {"etag": "x", "owner": "b"}}`,
+			`{"name": "a", "meta": {"owner": "b"}}`,
+		},
+		{
+			"rename",
+			Mapping{Rename: map[string]string{"name": "title", "meta.owner": "owner", "missing": "other"}},
+			`{"name": "a", "meta": {"owner": "b"}}`,
+			`{"title": "a", "owner": "b", "meta": {}}`,
+		},
+		{
+			"chained rename",
+			Mapping{Rename: map[string]string{"a": "b", "b": "c", "c": "d"}},
+			`{"a": 1, "b": 2, "c": 3}`,
+			`{"b": 1, "c": 2, "d": 3}`,
+		},
+		{
+			"swap",
+			Mapping{Rename: map[string]string{"a": "b", "b": "a"}},
+			`{"a": 1, "b": 2}`,
+			`{"a": 2, "b": 1}`,
+		},
+		{
+			"replace",
+			Mapping{Replace: map[string]map[string]string{
+				"url":   {"staging": "prod"},
+				"count": {"1": "2"},
+			}},
+			`{"url": "https://staging.example.com/staging", "count": 1}`,
+			`{"url": "https://prod.example.com/prod", "count": 1}`,
+		},
+		{
+			"overlapping replace",
+			Mapping{Replace: map[string]map[string]string{
+				"url": {"stag": "x", "staging": "prod", "prod": "live"},
+			}},
+			`{"url": "staging.stag.prod"}`,
+			`{"url": "prod.x.live"}`,
+		},
+		{
+			"set",
+			Mapping{Set: map[string]interface{}{"env": "prod", "meta.replicas": 3.0}},
+			`{"env": "dev"}`,
+			`{"env": "prod", "meta": {"replicas": 3}}`,
+		},
+		{
+			"order",
+			Mapping{
+				Delete: []string{"title"},
+				Rename: map[string]string{"name": "title"},
+				Set:    map[string]interface{}{"name": "new"},
+			},
+			`{"name": "a", "title": "old"}`,
+			`{"title": "a", "name": "new"}`,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var want map[string]interface{}
+			if err := json.Unmarshal([]byte(test.want), &want); err != nil {
+				t.Fatal(err)
+			}
+			// map iteration order changes between runs
+			for i := 0; i < 20; i++ {
+				var object map[string]interface{}
+				if err := json.Unmarshal([]byte(test.input), &object); err != nil {
+					t.Fatal(err)
+				}
+				test.mapping.Apply(object)
+				if !reflect.DeepEqual(object, want) {
+					t.Fatalf("Apply(%s) = %v, want %v", test.input, object, want)
+				}
+			}
+		})
+	}
+}
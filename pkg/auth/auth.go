@@ -3,13 +3,13 @@ package auth
 import (
 	"encoding/json"
 	"errors"
-	"io/ioutil"
+	"fmt"
 	"net/http"
 	"net/url"
-	"os/user"
 	"strings"
 
 	cfg "github.com/fhivemind/go-hastily/config"
+	"github.com/fhivemind/go-hastily/pkg/transport"
 )
 
 // config loads environment configuration
@@ -23,6 +23,23 @@ type TokenResponse struct {
 	Type         string `json:"token_type"`
 	ExpiresIn    int    `json:"expires_in"`
 	Scope        string `json:"scope"`
+	// error response
+	Error            string `json:"error"`
+	ErrorDescription string `json:"error_description"`
+}
+
+// TokenError defines OAuth error returned by token endpoint.
+type TokenError struct {
+	Code        string
+	Description string
+}
+
+// Error formats OAuth error.
+func (err *TokenError) Error() string {
+	if err.Description != "" {
+		return fmt.Sprintf("Authentication failed: %s (%s)", err.Description, err.Code)
+	}
+	return fmt.Sprintf("Authentication failed: %s", err.Code)
 }
 
 // Credentials is the parent of all OAuth token related activities.
@@ -36,16 +53,6 @@ type Credentials struct {
 	Username     string `json:"username"`
 }
 
-// credentialsPath is a shared function that defines where
-// the credentials will be saved and loaded from.
-func credentialsPath() (string, error) {
-	myself, err := user.Current()
-	if err != nil {
-		return "", err
-	}
-	return myself.HomeDir + "/.go-hastly.json", nil
-}
-
 // Validate if credentials work as a safeguard for other commands.
 func (creds *Credentials) Validate() error {
 	if creds.AccessToken == "" {
@@ -54,47 +61,32 @@ func (creds *Credentials) Validate() error {
 	return nil
 }
 
-// Save method saves credentials to the file and updates
-// path of the object.
+// Save method saves credentials of the current context to
+// the configured store and updates path of the object.
 func (creds *Credentials) Save() error {
 
-	// obtain path
-	path, err := credentialsPath()
-	if err != nil {
-		return err
-	}
-	creds.Path = path
-	credsJson, _ := json.Marshal(creds)
-
-	// save file
-	err = ioutil.WriteFile(creds.Path, credsJson, 0644)
+	// obtain store
+	store, err := DefaultStore()
 	if err != nil {
-		creds.Path = ""
 		return err
 	}
 
-	return nil
+	// save credentials
+	return store.Save(currentContext(), creds)
 }
 
-// LoadCredentials function loads credentials saved on the system.
-// It throws error if there is no such file.
+// LoadCredentials function loads credentials of the current context
+// saved on the system. It throws error if there are no credentials.
 func LoadCredentials() (*Credentials, error) {
 
-	// obtain path
-	path, err := credentialsPath()
-	if err != nil {
-		return nil, err
-	}
-
-	// read file
-	data, err := ioutil.ReadFile(path)
+	// obtain store
+	store, err := DefaultStore()
 	if err != nil {
 		return nil, err
 	}
 
-	// load data into environment
-	var credentials Credentials
-	err = json.Unmarshal(data, &credentials)
+	// load credentials
+	credentials, err := store.Load(currentContext())
 	if err != nil {
 		return nil, err
 	}
@@ -105,25 +97,46 @@ func LoadCredentials() (*Credentials, error) {
 		return nil, err
 	}
 
-	return &credentials, nil
+	return credentials, nil
 }
 
-// GetCredentials obtains OAuth token required to work with backend API.
+// GetCredentials obtains OAuth token required to work with backend API
+// using the password grant.
 func GetCredentials(username string, password string) (*Credentials, error) {
 
-	// create request to obtain oauth token
-	data := url.Values{
+	// request oauth token
+	token, err := requestToken(url.Values{
 		"grant_type": {"password"},
 		"username":   {username},
 		"password":   {password},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return newCredentials(token, username)
+}
+
+// requestToken sends form to token endpoint and parses the token response.
+// OAuth error responses are returned as *TokenError.
+func requestToken(data url.Values) (*TokenResponse, error) {
+
+	// create request to obtain oauth token
+	if envCfg.OAuth.ClientID != "" && data.Get("client_id") == "" {
+		data.Set("client_id", envCfg.OAuth.ClientID)
+	}
+	req, err := http.NewRequest("POST", tokenEndpoint(), strings.NewReader(data.Encode()))
+	if err != nil {
+		return nil, err
 	}
-	req, err := http.NewRequest("POST", envCfg.LoginEndpoint, strings.NewReader(data.Encode()))
 	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
 	req.Header.Set("Accept", "application/json")
-	req.Header.Set("Authorization", "Basic")
 
 	// send request
-	client := &http.Client{}
+	client, err := transport.Client()
+	if err != nil {
+		return nil, err
+	}
 	resp, err := client.Do(req)
 	if err != nil {
 		return nil, err
@@ -136,6 +149,15 @@ func GetCredentials(username string, password string) (*Credentials, error) {
 	if err != nil {
 		return nil, err
 	}
+	if token.Error != "" {
+		return nil, &TokenError{Code: token.Error, Description: token.ErrorDescription}
+	}
+
+	return &token, nil
+}
+
+// newCredentials creates verified credentials from token response.
+func newCredentials(token *TokenResponse, username string) (*Credentials, error) {
 
 	// serve credentials
 	var credentials = Credentials{
@@ -144,15 +166,37 @@ func GetCredentials(username string, password string) (*Credentials, error) {
 		TokenId:      token.TokenId,
 		Type:         token.Type,
 		Username:     username,
-		Endpoint:     envCfg.LoginEndpoint,
+		Endpoint:     tokenEndpoint(),
 		Path:         "",
 	}
 
 	// verify credentials
-	err = credentials.Validate()
+	err := credentials.Validate()
 	if err != nil {
 		return nil, err
 	}
 
 	return &credentials, nil
 }
+
+// setScope adds configured scopes to OAuth request. Some servers reject an
+// empty scope, so nothing is added if no scopes are configured.
+func setScope(values url.Values) {
+	if len(envCfg.OAuth.Scopes) > 0 {
+		values.Set("scope", strings.Join(envCfg.OAuth.Scopes, " "))
+	}
+}
+
+// LoginConfigured checks if login mode has a token endpoint, either
+// oauth.token_endpoint or the legacy login endpoint.
+func LoginConfigured() bool {
+	return tokenEndpoint() != ""
+}
+
+// tokenEndpoint returns OAuth token endpoint, which defaults to login endpoint.
+func tokenEndpoint() string {
+	if envCfg.OAuth.TokenEndpoint != "" {
+		return envCfg.OAuth.TokenEndpoint
+	}
+	return envCfg.LoginEndpoint
+}
@@ -0,0 +1,115 @@
+package auth
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/fhivemind/go-hastily/pkg/transport"
+)
+
+// deviceGrantType is the grant type of RFC 8628 token requests.
+const deviceGrantType = "urn:ietf:params:oauth:grant-type:device_code"
+
+// DeviceCode defines response of device authorization request.
+type DeviceCode struct {
+	DeviceCode              string `json:"device_code"`
+	UserCode                string `json:"user_code"`
+	VerificationURI         string `json:"verification_uri"`
+	VerificationURIComplete string `json:"verification_uri_complete"`
+	ExpiresIn               int    `json:"expires_in"`
+	Interval                int    `json:"interval"`
+}
+
+// GetDeviceCredentials obtains OAuth token using the device authorization
+// grant (RFC 8628). The notify function is called once with the code which
+// user should enter at the verification URI, after which the token endpoint
+// is polled until user approves or denies the request.
+func GetDeviceCredentials(notify func(*DeviceCode)) (*Credentials, error) {
+	if envCfg.OAuth.DeviceEndpoint == "" {
+		return nil, errors.New("Device login requires oauth.device_endpoint to be configured.")
+	}
+
+	// request device code
+	code, err := requestDeviceCode()
+	if err != nil {
+		return nil, err
+	}
+	notify(code)
+
+	// poll for token
+	interval := time.Duration(code.Interval) * time.Second
+	if interval <= 0 {
+		interval = 5 * time.Second
+	}
+	deadline := time.Now().Add(time.Duration(code.ExpiresIn) * time.Second)
+	for code.ExpiresIn <= 0 || time.Now().Before(deadline) {
+		time.Sleep(interval)
+
+		token, err := requestToken(url.Values{
+			"grant_type":  {deviceGrantType},
+			"device_code": {code.DeviceCode},
+		})
+		if tokenErr, ok := err.(*TokenError); ok {
+			switch tokenErr.Code {
+			case "authorization_pending":
+				continue
+			case "slow_down":
+				interval += 5 * time.Second
+				continue
+			}
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		return newCredentials(token, "")
+	}
+
+	return nil, errors.New("Device code expired before the login was approved.")
+}
+
+// requestDeviceCode starts device authorization.
+func requestDeviceCode() (*DeviceCode, error) {
+
+	// create request
+	data := url.Values{
+		"client_id": {envCfg.OAuth.ClientID},
+	}
+	setScope(data)
+	req, err := http.NewRequest("POST", envCfg.OAuth.DeviceEndpoint, strings.NewReader(data.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+
+	// send request
+	client, err := transport.Client()
+	if err != nil {
+		return nil, err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	// parse response
+	var payload struct {
+		DeviceCode
+		Error            string `json:"error"`
+		ErrorDescription string `json:"error_description"`
+	}
+	if err = json.NewDecoder(resp.Body).Decode(&payload); err != nil {
+		return nil, err
+	}
+	if payload.Error != "" {
+		return nil, &TokenError{Code: payload.Error, Description: payload.ErrorDescription}
+	}
+
+	return &payload.DeviceCode, nil
+}
@@ -0,0 +1,66 @@
+package auth
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math"
+	"strings"
+	"time"
+)
+
+// Claims holds commonly used claims of a decoded JWT. Times are NumericDate
+// values, i.e. seconds since epoch which may have a fraction.
+type Claims struct {
+	Subject   string      `json:"sub"`
+	Email     string      `json:"email"`
+	Name      string      `json:"name"`
+	Issuer    string      `json:"iss"`
+	Audience  interface{} `json:"aud"`
+	Scope     string      `json:"scope"`
+	Scopes    []string    `json:"scp"`
+	ExpiresAt float64     `json:"exp"`
+	IssuedAt  float64     `json:"iat"`
+}
+
+// DecodeJWT decodes claims of a JWT without verifying its signature.
+// Use only for displaying information about own tokens.
+func DecodeJWT(token string) (*Claims, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, errors.New("Token is not a JWT.")
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(parts[1], "="))
+	if err != nil {
+		return nil, err
+	}
+
+	var claims Claims
+	if err = json.Unmarshal(payload, &claims); err != nil {
+		return nil, err
+	}
+	return &claims, nil
+}
+
+// Expiry returns expiration time, or zero time if the token does not expire.
+func (claims *Claims) Expiry() time.Time {
+	if claims.ExpiresAt == 0 {
+		return time.Time{}
+	}
+	return numericDate(claims.ExpiresAt)
+}
+
+// numericDate converts seconds since epoch into time.
+func numericDate(seconds float64) time.Time {
+	whole, fraction := math.Modf(seconds)
+	return time.Unix(int64(whole), int64(fraction*1e9))
+}
+
+// ScopeList returns granted scopes from either scope or scp claim.
+func (claims *Claims) ScopeList() []string {
+	if len(claims.Scopes) > 0 {
+		return claims.Scopes
+	}
+	return strings.Fields(claims.Scope)
+}
@@ -0,0 +1,44 @@
+package auth
+
+import (
+	"encoding/base64"
+	"testing"
+	"time"
+)
+
+func TestDecodeJWT(t *testing.T) {
+	tests := []struct {
+		name    string
+		payload string
+		expiry  time.Time
+		scopes  int
+		wantErr bool
+	}{
+		{"integer exp", `{"exp":1700000000,"scope":"read write"}`, time.Unix(1700000000, 0), 2, false},
+		{"fractional exp", `{"exp":1700000000.5,"iat":1699999999.25,"scp":["read"]}`, time.Unix(1700000000, 5e8), 1, false},
+		{"no exp", `{"sub":"user"}`, time.Time{}, 0, false},
+		{"invalid exp", `{"exp":"soon"}`, time.Time{}, 0, true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			token := "e30." + base64.RawURLEncoding.EncodeToString([]byte(test.payload)) + ".sig"
+			claims, err := DecodeJWT(token)
+			if (err != nil) != test.wantErr {
+				t.Fatalf("DecodeJWT(%s) error = %v, want error %v", test.payload, err, test.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if expiry := claims.Expiry(); !expiry.Equal(test.expiry) {
+				t.Errorf("Expiry() = %v, want %v", expiry, test.expiry)
+			}
+			if scopes := claims.ScopeList(); len(scopes) != test.scopes {
+				t.Errorf("ScopeList() = %v, want %d scopes", scopes, test.scopes)
+			}
+		})
+	}
+
+	if _, err := DecodeJWT("opaque-token"); err == nil {
+		t.Error("DecodeJWT(opaque-token) succeeded")
+	}
+}
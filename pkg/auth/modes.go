@@ -0,0 +1,188 @@
+package auth
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+)
+
+// Authentication modes selectable per context.
+const (
+	// ModeLogin uses credentials saved by the login command.
+	ModeLogin = "login"
+	// ModeClientCredentials uses the OAuth client_credentials grant.
+	ModeClientCredentials = "client_credentials"
+	// ModeAPIKey sends a static API key in a header or query param.
+	ModeAPIKey = "api_key"
+	// ModeBasic uses HTTP Basic authentication.
+	ModeBasic = "basic"
+	// ModeBearer sends a static bearer token.
+	ModeBearer = "bearer"
+	// ModeHMAC signs requests with a shared secret.
+	ModeHMAC = "hmac"
+)
+
+// Default environment variables holding secrets for non-login modes.
+const (
+	defaultClientSecretEnv = "GO_HASTILY_CLIENT_SECRET"
+	defaultTokenEnv        = "GO_HASTILY_TOKEN"
+	defaultAPIKeyEnv       = "GO_HASTILY_API_KEY"
+	defaultUsernameEnv     = "GO_HASTILY_USERNAME"
+	defaultPasswordEnv     = "GO_HASTILY_PASSWORD"
+	defaultAPIKeyHeader    = "X-API-Key"
+)
+
+// Authenticator applies authentication to outgoing requests.
+type Authenticator interface {
+	Apply(req *http.Request) error
+}
+
+// Mode returns authentication mode of the current context.
+func Mode() string {
+	if envCfg.Auth.Mode == "" {
+		return ModeLogin
+	}
+	return envCfg.Auth.Mode
+}
+
+// NewAuthenticator creates Authenticator for the mode of the current context.
+func NewAuthenticator() (Authenticator, error) {
+	settings := envCfg.Auth
+	switch Mode() {
+	case ModeLogin:
+		if !LoginConfigured() {
+			return &Credentials{}, nil
+		}
+		return LoadCredentials()
+	case ModeClientCredentials:
+		secret, err := secretFromEnv(settings.ClientSecretEnv, defaultClientSecretEnv)
+		if err != nil {
+			return nil, err
+		}
+		return &clientCredentialsAuth{Secret: secret}, nil
+	case ModeAPIKey:
+		key, err := secretFromEnv(settings.TokenEnv, defaultAPIKeyEnv)
+		if err != nil {
+			return nil, err
+		}
+		header := settings.Header
+		if header == "" && settings.Query == "" {
+			header = defaultAPIKeyHeader
+		}
+		return &apiKeyAuth{Header: header, Query: settings.Query, Key: key}, nil
+	case ModeBasic:
+		username, err := secretFromEnv(settings.UsernameEnv, defaultUsernameEnv)
+		if err != nil {
+			return nil, err
+		}
+		password, err := secretFromEnv(settings.PasswordEnv, defaultPasswordEnv)
+		if err != nil {
+			return nil, err
+		}
+		return &basicAuth{Username: username, Password: password}, nil
+	case ModeBearer:
+		token, err := secretFromEnv(settings.TokenEnv, defaultTokenEnv)
+		if err != nil {
+			return nil, err
+		}
+		return &Credentials{AccessToken: token, Type: "Bearer"}, nil
+	case ModeHMAC:
+		return newHMACAuth()
+	}
+	return nil, fmt.Errorf("Unknown authentication mode %q.", envCfg.Auth.Mode)
+}
+
+// Apply sets Authorization header using the token type, bearer by default.
+func (creds *Credentials) Apply(req *http.Request) error {
+	if creds.AccessToken == "" {
+		return nil
+	}
+	scheme := creds.Type
+	if scheme == "" || strings.EqualFold(scheme, "bearer") {
+		scheme = "Bearer"
+	}
+	req.Header.Set("Authorization", fmt.Sprintf("%s %s", scheme, creds.AccessToken))
+	return nil
+}
+
+// secretFromEnv reads a required secret from configured or default env variable.
+func secretFromEnv(name string, def string) (string, error) {
+	if name == "" {
+		name = def
+	}
+	value := os.Getenv(name)
+	if value == "" {
+		return "", fmt.Errorf("Environment variable %s is required for %s authentication.", name, Mode())
+	}
+	return value, nil
+}
+
+// clientCredentialsAuth obtains token with the OAuth client_credentials grant
+// on first use and reuses it for the rest of the process.
+type clientCredentialsAuth struct {
+	Secret string
+	once   sync.Once
+	creds  *Credentials
+	err    error
+}
+
+// Apply sets Authorization header with a client_credentials token.
+func (cc *clientCredentialsAuth) Apply(req *http.Request) error {
+	cc.once.Do(func() {
+		if envCfg.OAuth.ClientID == "" {
+			cc.err = errors.New("Client credentials authentication requires oauth.client_id to be configured.")
+			return
+		}
+		data := url.Values{
+			"grant_type":    {"client_credentials"},
+			"client_secret": {cc.Secret},
+		}
+		setScope(data)
+		token, err := requestToken(data)
+		if err != nil {
+			cc.err = err
+			return
+		}
+		cc.creds, cc.err = newCredentials(token, envCfg.OAuth.ClientID)
+	})
+	if cc.err != nil {
+		return cc.err
+	}
+	return cc.creds.Apply(req)
+}
+
+// apiKeyAuth sends static API key in a header or query param.
+type apiKeyAuth struct {
+	Header string
+	Query  string
+	Key    string
+}
+
+// Apply adds API key to request.
+func (key *apiKeyAuth) Apply(req *http.Request) error {
+	if key.Header != "" {
+		req.Header.Set(key.Header, key.Key)
+	}
+	if key.Query != "" {
+		query := req.URL.Query()
+		query.Set(key.Query, key.Key)
+		req.URL.RawQuery = query.Encode()
+	}
+	return nil
+}
+
+// basicAuth uses HTTP Basic authentication.
+type basicAuth struct {
+	Username string
+	Password string
+}
+
+// Apply sets Basic Authorization header.
+func (basic *basicAuth) Apply(req *http.Request) error {
+	req.SetBasicAuth(basic.Username, basic.Password)
+	return nil
+}
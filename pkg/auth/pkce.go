@@ -0,0 +1,126 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// browserLoginTimeout limits how long we wait for the redirect.
+const browserLoginTimeout = 5 * time.Minute
+
+// GetBrowserCredentials obtains OAuth token using the authorization code grant
+// with PKCE (RFC 7636). A loopback listener receives the redirect, and the open
+// function is called with the URL which user should visit to log in.
+func GetBrowserCredentials(open func(string)) (*Credentials, error) {
+	if envCfg.OAuth.AuthorizeEndpoint == "" {
+		return nil, errors.New("Browser login requires oauth.authorize_endpoint to be configured.")
+	}
+
+	// pkce and state
+	verifier, err := randomString(32)
+	if err != nil {
+		return nil, err
+	}
+	state, err := randomString(16)
+	if err != nil {
+		return nil, err
+	}
+	challenge := sha256.Sum256([]byte(verifier))
+
+	// start loopback listener
+	listener, err := net.Listen("tcp", fmt.Sprintf("127.0.0.1:%d", envCfg.OAuth.RedirectPort))
+	if err != nil {
+		return nil, err
+	}
+	defer listener.Close()
+	redirectURI := fmt.Sprintf("http://%s/callback", listener.Addr().String())
+
+	// wait for redirect
+	type result struct {
+		code string
+		err  error
+	}
+	results := make(chan result, 1)
+	server := &http.Server{Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/callback" {
+			http.NotFound(w, r)
+			return
+		}
+		query := r.URL.Query()
+		res := result{code: query.Get("code")}
+		switch {
+		case query.Get("error") != "":
+			res.err = &TokenError{Code: query.Get("error"), Description: query.Get("error_description")}
+		case query.Get("state") != state:
+			res.err = errors.New("Login failed: state mismatch in redirect.")
+		case res.code == "":
+			res.err = errors.New("Login failed: no authorization code in redirect.")
+		}
+		if res.err != nil {
+			http.Error(w, res.err.Error(), http.StatusBadRequest)
+		} else {
+			fmt.Fprintln(w, "Login successful. You can close this window and return to go-hastily.")
+		}
+		select {
+		case results <- res:
+		default:
+		}
+	})}
+	go server.Serve(listener)
+	defer server.Close()
+
+	// send user to authorization endpoint
+	authURL, err := url.Parse(envCfg.OAuth.AuthorizeEndpoint)
+	if err != nil {
+		return nil, err
+	}
+	query := authURL.Query()
+	query.Set("response_type", "code")
+	query.Set("client_id", envCfg.OAuth.ClientID)
+	query.Set("redirect_uri", redirectURI)
+	setScope(query)
+	query.Set("state", state)
+	query.Set("code_challenge", base64.RawURLEncoding.EncodeToString(challenge[:]))
+	query.Set("code_challenge_method", "S256")
+	authURL.RawQuery = query.Encode()
+	open(authURL.String())
+
+	var res result
+	select {
+	case res = <-results:
+	case <-time.After(browserLoginTimeout):
+		return nil, errors.New("Timed out waiting for browser login.")
+	}
+	if res.err != nil {
+		return nil, res.err
+	}
+
+	// exchange code for token
+	token, err := requestToken(url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {res.code},
+		"redirect_uri":  {redirectURI},
+		"code_verifier": {verifier},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return newCredentials(token, "")
+}
+
+// randomString returns url-safe random string of n bytes of entropy.
+func randomString(n int) (string, error) {
+	buf := make([]byte, n)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
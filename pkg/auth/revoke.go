@@ -0,0 +1,111 @@
+package auth
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+
+	"github.com/fhivemind/go-hastily/pkg/transport"
+)
+
+// Revoke invalidates refresh and access tokens on the revocation endpoint
+// (RFC 7009). It does nothing if no endpoint is configured.
+func (creds *Credentials) Revoke() error {
+	if envCfg.OAuth.RevocationEndpoint == "" {
+		return nil
+	}
+
+	// revoke refresh token first so that no new access tokens can be issued
+	tokens := []struct{ value, hint string }{
+		{creds.RefreshToken, "refresh_token"},
+		{creds.AccessToken, "access_token"},
+	}
+	for _, token := range tokens {
+		if token.value == "" {
+			continue
+		}
+		if err := revokeToken(token.value, token.hint); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// RevokeError reports tokens which could not be revoked on logout. Local
+// credentials are deleted regardless.
+type RevokeError struct {
+	Err error
+}
+
+// Error describes failed revocation.
+func (err *RevokeError) Error() string {
+	return fmt.Sprintf("Unable to revoke tokens, local credentials were removed anyway: %v", err.Err)
+}
+
+// Logout revokes and deletes stored credentials of the current context.
+// Returns false if there were no stored credentials. Credentials are deleted
+// even if revocation fails, which is reported as *RevokeError.
+func Logout() (bool, error) {
+
+	// obtain store
+	store, err := DefaultStore()
+	if err != nil {
+		return false, err
+	}
+
+	// revoke stored tokens
+	creds, err := store.Load(currentContext())
+	if os.IsNotExist(err) {
+		return false, nil
+	}
+	var revokeErr error
+	if err == nil {
+		revokeErr = creds.Revoke()
+	}
+
+	// delete credentials even if they could not be read or revoked
+	if err = store.Delete(currentContext()); err != nil {
+		return true, err
+	}
+	if revokeErr != nil {
+		return true, &RevokeError{Err: revokeErr}
+	}
+	return true, nil
+}
+
+// revokeToken sends single token revocation request.
+func revokeToken(token string, hint string) error {
+
+	// create request
+	data := url.Values{
+		"token":           {token},
+		"token_type_hint": {hint},
+	}
+	if envCfg.OAuth.ClientID != "" {
+		data.Set("client_id", envCfg.OAuth.ClientID)
+	}
+	req, err := http.NewRequest("POST", envCfg.OAuth.RevocationEndpoint, strings.NewReader(data.Encode()))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	// send request
+	client, err := transport.Client()
+	if err != nil {
+		return err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	// invalid tokens are also answered with 200
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("Revoking %s failed with HTTP status %d.", strings.Replace(hint, "_", " ", -1), resp.StatusCode)
+	}
+	return nil
+}
@@ -0,0 +1,175 @@
+package auth
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Defaults of the hmac mode.
+const (
+	defaultSigningSecretEnv = "GO_HASTILY_SIGNING_SECRET"
+	defaultSignatureHeader  = "X-Signature"
+	defaultKeyIDHeader      = "X-Key-Id"
+	defaultTimestampHeader  = "X-Timestamp"
+	signingAlgorithm        = "HMAC-SHA256"
+)
+
+// hmacAuth signs requests with HMAC-SHA256 over a canonical form of method,
+// path, sorted query, signed headers, timestamp and body hash.
+type hmacAuth struct {
+	KeyID           string
+	Secret          []byte
+	Header          string
+	KeyIDHeader     string
+	TimestampHeader string
+	SignedHeaders   []string
+	Encoding        string
+	TimestampFormat string
+	now             func() time.Time
+}
+
+// newHMACAuth creates signer from settings of the current context.
+func newHMACAuth() (*hmacAuth, error) {
+	settings := envCfg.Auth.Signing
+	if settings.KeyID == "" {
+		return nil, errors.New("Hmac authentication requires auth.signing.key_id to be configured.")
+	}
+	secret, err := secretFromEnv(settings.SecretEnv, defaultSigningSecretEnv)
+	if err != nil {
+		return nil, err
+	}
+	return &hmacAuth{
+		KeyID:           settings.KeyID,
+		Secret:          []byte(secret),
+		Header:          valueOr(settings.Header, defaultSignatureHeader),
+		KeyIDHeader:     valueOr(settings.KeyIDHeader, defaultKeyIDHeader),
+		TimestampHeader: valueOr(settings.TimestampHeader, defaultTimestampHeader),
+		SignedHeaders:   settings.SignedHeaders,
+		Encoding:        settings.Encoding,
+		TimestampFormat: settings.TimestampFormat,
+		now:             time.Now,
+	}, nil
+}
+
+// Apply sets timestamp, key ID and signature headers. It must run after all
+// other changes to the request, as the signature covers them.
+func (signer *hmacAuth) Apply(req *http.Request) error {
+	body, err := readBody(req)
+	if err != nil {
+		return err
+	}
+
+	// timestamp
+	now := signer.now()
+	timestamp := strconv.FormatInt(now.Unix(), 10)
+	if signer.TimestampFormat == "rfc3339" {
+		timestamp = now.UTC().Format(time.RFC3339)
+	}
+	req.Header.Set(signer.TimestampHeader, timestamp)
+
+	// signature
+	mac := hmac.New(sha256.New, signer.Secret)
+	mac.Write([]byte(signer.canonicalRequest(req, timestamp, body)))
+	sum := mac.Sum(nil)
+	signature := hex.EncodeToString(sum)
+	if signer.Encoding == "base64" {
+		signature = base64.StdEncoding.EncodeToString(sum)
+	}
+
+	// Authorization header carries all values in a single scheme
+	if strings.EqualFold(signer.Header, "Authorization") {
+		req.Header.Set("Authorization", fmt.Sprintf("%s Credential=%s, SignedHeaders=%s, Signature=%s",
+			signingAlgorithm, signer.KeyID, strings.Join(signer.signedHeaderNames(), ";"), signature))
+		return nil
+	}
+	req.Header.Set(signer.KeyIDHeader, signer.KeyID)
+	req.Header.Set(signer.Header, signature)
+	return nil
+}
+
+// canonicalRequest builds the string to sign, one element per line:
+// method, path, sorted query, signed headers, signed header names,
+// timestamp and hex encoded SHA-256 of the body.
+func (signer *hmacAuth) canonicalRequest(req *http.Request, timestamp string, body []byte) string {
+	path := req.URL.EscapedPath()
+	if path == "" {
+		path = "/"
+	}
+
+	// sorted query
+	query := req.URL.Query()
+	var params []string
+	for key, values := range query {
+		for _, value := range values {
+			params = append(params, url.QueryEscape(key)+"="+url.QueryEscape(value))
+		}
+	}
+	sort.Strings(params)
+
+	// signed headers
+	names := signer.signedHeaderNames()
+	var headers []string
+	for _, name := range names {
+		value := req.Header.Get(name)
+		if name == "host" {
+			value = req.URL.Host
+		}
+		headers = append(headers, name+":"+strings.TrimSpace(value))
+	}
+
+	hash := sha256.Sum256(body)
+	return strings.Join([]string{
+		req.Method,
+		path,
+		strings.Join(params, "&"),
+		strings.Join(headers, "\n"),
+		strings.Join(names, ";"),
+		timestamp,
+		hex.EncodeToString(hash[:]),
+	}, "\n")
+}
+
+// signedHeaderNames returns sorted lowercase names of signed headers.
+func (signer *hmacAuth) signedHeaderNames() []string {
+	var names []string
+	for _, name := range signer.SignedHeaders {
+		names = append(names, strings.ToLower(name))
+	}
+	sort.Strings(names)
+	return names
+}
+
+// readBody returns a copy of request body without consuming it.
+func readBody(req *http.Request) ([]byte, error) {
+	if req.Body == nil || req.Body == http.NoBody {
+		return nil, nil
+	}
+	if req.GetBody == nil {
+		return nil, fmt.Errorf("Unable to sign %s %s, request body can not be re-read.", req.Method, req.URL)
+	}
+	body, err := req.GetBody()
+	if err != nil {
+		return nil, err
+	}
+	defer body.Close()
+	return ioutil.ReadAll(body)
+}
+
+// valueOr returns value, or def if value is empty.
+func valueOr(value string, def string) string {
+	if value == "" {
+		return def
+	}
+	return value
+}
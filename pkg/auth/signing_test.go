@@ -0,0 +1,89 @@
+package auth
+
+import (
+	"bytes"
+	"net/http"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestHMACAuthApply(t *testing.T) {
+	tests := []struct {
+		name    string
+		signer  hmacAuth
+		headers map[string]string
+	}{
+		{
+			"hex signature",
+			hmacAuth{Header: "X-Signature"},
+			map[string]string{
+				"X-Timestamp": "1700000000",
+				"X-Key-Id":    "key",
+				"X-Signature": "17e1f4407434b0a14edd098de6f2af419252bbec3dfb605d2c21bf4d979b7591",
+			},
+		},
+		{
+			"base64 signature with rfc3339 timestamp",
+			hmacAuth{Header: "X-Signature", Encoding: "base64", TimestampFormat: "rfc3339"},
+			map[string]string{
+				"X-Timestamp": "2023-11-14T22:13:20Z",
+				"X-Signature": "PElA9JQxN0wuzRfrGhzLjrYsfmHhAoA6DZdss/x/ipc=",
+			},
+		},
+		{
+			"authorization header",
+			hmacAuth{Header: "Authorization"},
+			map[string]string{
+				"Authorization": "HMAC-SHA256 Credential=key, SignedHeaders=content-type;host, " +
+					"Signature=17e1f4407434b0a14edd098de6f2af419252bbec3dfb605d2c21bf4d979b7591",
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			// clock moves on every call, so signature only matches if it is read once
+			calls := 0
+			signer := test.signer
+			signer.KeyID, signer.Secret = "key", []byte("secret")
+			signer.KeyIDHeader, signer.TimestampHeader = "X-Key-Id", "X-Timestamp"
+			signer.SignedHeaders = []string{"Host", "Content-Type"}
+			signer.now = func() time.Time {
+				calls++
+				return time.Unix(1699999999+int64(calls), 0)
+			}
+
+			req, err := http.NewRequest("POST", "http://example.com/api/users?b=2&a=1", bytes.NewBufferString(`{"id":1}`))
+			if err != nil {
+				t.Fatal(err)
+			}
+			req.Header.Set("Content-Type", "application/json")
+			if err = signer.Apply(req); err != nil {
+				t.Fatal(err)
+			}
+			for name, want := range test.headers {
+				if got := req.Header.Get(name); got != want {
+					t.Errorf("header %s = %q, want %q", name, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestNewHMACAuthRequiresKeyAndSecret(t *testing.T) {
+	signing := envCfg.Auth.Signing
+	defer func() { envCfg.Auth.Signing = signing }()
+	defer os.Setenv(defaultSigningSecretEnv, os.Getenv(defaultSigningSecretEnv))
+
+	envCfg.Auth.Signing.KeyID = ""
+	os.Setenv(defaultSigningSecretEnv, "secret")
+	if _, err := newHMACAuth(); err == nil {
+		t.Error("newHMACAuth() without key ID succeeded")
+	}
+
+	envCfg.Auth.Signing.KeyID = "key"
+	os.Unsetenv(defaultSigningSecretEnv)
+	if _, err := newHMACAuth(); err == nil {
+		t.Error("newHMACAuth() without secret succeeded")
+	}
+}
@@ -0,0 +1,175 @@
+package auth
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/user"
+	"path/filepath"
+
+	cfg "github.com/fhivemind/go-hastily/config"
+)
+
+// defaultContext names credentials used when no context is selected.
+const defaultContext = "default"
+
+// Store defines a backend which persists credentials per context.
+type Store interface {
+	Load(context string) (*Credentials, error)
+	Save(context string, creds *Credentials) error
+	Delete(context string) error
+}
+
+// DefaultStore returns credential store selected in config.
+func DefaultStore() (Store, error) {
+	settings := envCfg.Credentials
+	switch settings.Store {
+	case "", "file":
+		return &fileStore{}, nil
+	case "encrypted":
+		return &encryptedStore{KeyFile: settings.KeyFile}, nil
+	case "helper":
+		if settings.Helper == "" {
+			return nil, fmt.Errorf("Credential helper store requires credentials.helper to be configured.")
+		}
+		return &helperStore{Helper: settings.Helper}, nil
+	}
+	return nil, fmt.Errorf("Unknown credential store %q.", settings.Store)
+}
+
+// currentContext returns name of context used as credentials key.
+func currentContext() string {
+	if envCfg.Context != "" {
+		return envCfg.Context
+	}
+	return defaultContext
+}
+
+// credentialsPath is a shared function that defines where
+// the credentials of a context will be saved and loaded from.
+func credentialsPath(context string, ext string) (string, error) {
+	dir, err := cfg.Dir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "credentials", context+ext), nil
+}
+
+// legacyCredentialsPath returns location used by older versions.
+func legacyCredentialsPath() (string, error) {
+	myself, err := user.Current()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(myself.HomeDir, ".go-hastly.json"), nil
+}
+
+// writeFileAtomic writes data to a private temp file and renames it into place.
+func writeFileAtomic(path string, data []byte) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+
+	tmp, err := ioutil.TempFile(filepath.Dir(path), ".tmp-"+filepath.Base(path))
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if err = tmp.Chmod(0600); err == nil {
+		if _, err = tmp.Write(data); err == nil {
+			err = tmp.Sync()
+		}
+	}
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// fileStore saves credentials as plain json readable only by the owner.
+type fileStore struct{}
+
+// Load reads credentials of a context. Credentials saved by older
+// versions are migrated on first load.
+func (store *fileStore) Load(context string) (*Credentials, error) {
+	path, err := credentialsPath(context, ".json")
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) && context == defaultContext {
+		return store.migrateLegacy(context)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var creds Credentials
+	if err = json.Unmarshal(data, &creds); err != nil {
+		return nil, err
+	}
+	creds.Path = path
+	return &creds, nil
+}
+
+// Save writes credentials of a context.
+func (store *fileStore) Save(context string, creds *Credentials) error {
+	path, err := credentialsPath(context, ".json")
+	if err != nil {
+		return err
+	}
+	data, err := json.Marshal(creds)
+	if err != nil {
+		return err
+	}
+	if err = writeFileAtomic(path, data); err != nil {
+		return err
+	}
+	creds.Path = path
+	return nil
+}
+
+// Delete removes credentials of a context.
+func (store *fileStore) Delete(context string) error {
+	path, err := credentialsPath(context, ".json")
+	if err != nil {
+		return err
+	}
+	return removeIfExists(path)
+}
+
+// migrateLegacy moves world-readable credentials of older versions into the
+// store. Older versions had no contexts, so only the default context gets them.
+func (store *fileStore) migrateLegacy(context string) (*Credentials, error) {
+	legacy, err := legacyCredentialsPath()
+	if err != nil {
+		return nil, err
+	}
+	data, err := ioutil.ReadFile(legacy)
+	if err != nil {
+		return nil, err
+	}
+
+	var creds Credentials
+	if err = json.Unmarshal(data, &creds); err != nil {
+		return nil, err
+	}
+	if err = store.Save(context, &creds); err != nil {
+		return nil, err
+	}
+	return &creds, os.Remove(legacy)
+}
+
+// removeIfExists deletes file and ignores missing files.
+func removeIfExists(path string) error {
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,171 @@
+package auth
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"os"
+
+	"github.com/fhivemind/go-hastily/pkg/common"
+	"golang.org/x/crypto/pbkdf2"
+)
+
+const (
+	// passphraseEnv holds passphrase for non-interactive use.
+	passphraseEnv = "GO_HASTILY_PASSPHRASE"
+	// kdfIterations is the number of PBKDF2 rounds used to derive keys.
+	kdfIterations = 200000
+	// minKDFIterations and maxKDFIterations bound rounds accepted from files.
+	minKDFIterations = 10000
+	maxKDFIterations = 10000000
+	// saltSize is the size of random salt in bytes.
+	saltSize = 16
+	// encryptedVersion is the version of encrypted file format.
+	encryptedVersion = 1
+)
+
+// encryptedFile is the on-disk format of encrypted credentials.
+type encryptedFile struct {
+	Version    int    `json:"version"`
+	Iterations int    `json:"iterations"`
+	Salt       []byte `json:"salt"`
+	Nonce      []byte `json:"nonce"`
+	Data       []byte `json:"data"`
+}
+
+// encryptedStore saves credentials encrypted with AES-256-GCM. The key is derived
+// from contents of KeyFile, or from a passphrase if no key file is configured.
+type encryptedStore struct {
+	KeyFile string
+}
+
+// Load decrypts credentials of a context.
+func (store *encryptedStore) Load(context string) (*Credentials, error) {
+	path, err := credentialsPath(context, ".enc")
+	if err != nil {
+		return nil, err
+	}
+	raw, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var file encryptedFile
+	if err = json.Unmarshal(raw, &file); err != nil {
+		return nil, err
+	}
+	if file.Version != encryptedVersion {
+		return nil, errors.New("Unsupported encrypted credentials version.")
+	}
+	if file.Iterations < minKDFIterations || file.Iterations > maxKDFIterations || len(file.Salt) < saltSize {
+		return nil, errors.New("Encrypted credentials file is corrupted.")
+	}
+
+	gcm, err := store.cipher(file.Salt, file.Iterations)
+	if err != nil {
+		return nil, err
+	}
+	if len(file.Nonce) != gcm.NonceSize() {
+		return nil, errors.New("Encrypted credentials file is corrupted.")
+	}
+	data, err := gcm.Open(nil, file.Nonce, file.Data, []byte(context))
+	if err != nil {
+		return nil, errors.New("Unable to decrypt credentials. Wrong passphrase or key file?")
+	}
+
+	var creds Credentials
+	if err = json.Unmarshal(data, &creds); err != nil {
+		return nil, err
+	}
+	creds.Path = path
+	return &creds, nil
+}
+
+// Save encrypts credentials of a context.
+func (store *encryptedStore) Save(context string, creds *Credentials) error {
+	path, err := credentialsPath(context, ".enc")
+	if err != nil {
+		return err
+	}
+	data, err := json.Marshal(creds)
+	if err != nil {
+		return err
+	}
+
+	// encrypt
+	file := encryptedFile{
+		Version:    encryptedVersion,
+		Iterations: kdfIterations,
+		Salt:       make([]byte, saltSize),
+	}
+	if _, err = rand.Read(file.Salt); err != nil {
+		return err
+	}
+	gcm, err := store.cipher(file.Salt, file.Iterations)
+	if err != nil {
+		return err
+	}
+	file.Nonce = make([]byte, gcm.NonceSize())
+	if _, err = rand.Read(file.Nonce); err != nil {
+		return err
+	}
+	file.Data = gcm.Seal(nil, file.Nonce, data, []byte(context))
+
+	// save
+	raw, err := json.Marshal(file)
+	if err != nil {
+		return err
+	}
+	if err = writeFileAtomic(path, raw); err != nil {
+		return err
+	}
+	creds.Path = path
+	return nil
+}
+
+// Delete removes credentials of a context.
+func (store *encryptedStore) Delete(context string) error {
+	path, err := credentialsPath(context, ".enc")
+	if err != nil {
+		return err
+	}
+	return removeIfExists(path)
+}
+
+// cipher creates AES-GCM cipher with key derived from secret.
+func (store *encryptedStore) cipher(salt []byte, iterations int) (cipher.AEAD, error) {
+	secret, err := store.secret()
+	if err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(pbkdf2.Key(secret, salt, iterations, 32, sha256.New))
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// secret reads key file, passphrase from environment or prompts for it.
+func (store *encryptedStore) secret() ([]byte, error) {
+	if store.KeyFile != "" {
+		return ioutil.ReadFile(store.KeyFile)
+	}
+	if passphrase := os.Getenv(passphraseEnv); passphrase != "" {
+		return []byte(passphrase), nil
+	}
+	if !common.IsInteractive() {
+		return nil, errors.New("Unable to prompt for credentials passphrase, stdin is not a terminal. Set " + passphraseEnv + " or credentials.key_file.")
+	}
+	passphrase, err := common.PromptPassword("Credentials passphrase")
+	if err != nil {
+		return nil, err
+	}
+	if passphrase == "" {
+		return nil, errors.New("Passphrase is required for encrypted credentials.")
+	}
+	return []byte(passphrase), nil
+}
@@ -0,0 +1,146 @@
+package auth
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"testing"
+)
+
+func TestEncryptedStoreKeyDerivation(t *testing.T) {
+	// PBKDF2-HMAC-SHA256 test vectors from RFC 7914, section 11
+	tests := []struct {
+		passphrase string
+		salt       string
+		iterations int
+		key        string
+	}{
+		{"passwd", "salt", 1, "55ac046e56e3089fec1691c22544b605f94185216dde0465e68b9d57c20dacbc"},
+		{"Password", "NaCl", 80000, "4ddcd8f60b98be21830cee5ef22701f9641a4418d04c0414aeff08876b34ab56"},
+	}
+	defer os.Unsetenv(passphraseEnv)
+	for _, test := range tests {
+		t.Run(test.passphrase, func(t *testing.T) {
+			os.Setenv(passphraseEnv, test.passphrase)
+			store := &encryptedStore{}
+			got, err := store.cipher([]byte(test.salt), test.iterations)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			key, _ := hex.DecodeString(test.key)
+			block, err := aes.NewCipher(key)
+			if err != nil {
+				t.Fatal(err)
+			}
+			want, _ := cipher.NewGCM(block)
+
+			nonce := make([]byte, want.NonceSize())
+			plaintext := []byte("credentials")
+			if sealed := got.Seal(nil, nonce, plaintext, nil); !bytes.Equal(sealed, want.Seal(nil, nonce, plaintext, nil)) {
+				t.Errorf("cipher for %q/%q does not use key %s", test.passphrase, test.salt, test.key)
+			}
+		})
+	}
+}
+
+func TestEncryptedStoreRoundTrip(t *testing.T) {
+	dir, err := ioutil.TempDir("", "auth")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	defer os.Setenv("XDG_CONFIG_HOME", os.Getenv("XDG_CONFIG_HOME"))
+	os.Setenv("XDG_CONFIG_HOME", dir)
+	defer os.Unsetenv(passphraseEnv)
+	os.Setenv(passphraseEnv, "secret")
+
+	store := &encryptedStore{}
+	saved := &Credentials{AccessToken: "access", RefreshToken: "refresh", Username: "user"}
+	if err = store.Save("test", saved); err != nil {
+		t.Fatal(err)
+	}
+	raw, err := ioutil.ReadFile(saved.Path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Contains(raw, []byte("access")) || bytes.Contains(raw, []byte("refresh")) {
+		t.Errorf("credentials file holds plain tokens: %s", raw)
+	}
+
+	loaded, err := store.Load("test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *loaded != *saved {
+		t.Errorf("Load() = %+v, want %+v", loaded, saved)
+	}
+
+	// wrong passphrase and other context fail to decrypt
+	os.Setenv(passphraseEnv, "other")
+	if _, err = store.Load("test"); err == nil {
+		t.Error("Load() with wrong passphrase succeeded")
+	}
+	os.Setenv(passphraseEnv, "secret")
+	path, _ := credentialsPath("other", ".enc")
+	if err = os.Rename(saved.Path, path); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = store.Load("other"); err == nil {
+		t.Error("Load() of credentials moved to other context succeeded")
+	}
+}
+
+func TestEncryptedStoreRejectsCorruptedFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "auth")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	defer os.Setenv("XDG_CONFIG_HOME", os.Getenv("XDG_CONFIG_HOME"))
+	os.Setenv("XDG_CONFIG_HOME", dir)
+	defer os.Unsetenv(passphraseEnv)
+	os.Setenv(passphraseEnv, "secret")
+
+	store := &encryptedStore{}
+	creds := &Credentials{AccessToken: "access"}
+	if err = store.Save("test", creds); err != nil {
+		t.Fatal(err)
+	}
+	raw, err := ioutil.ReadFile(creds.Path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		corrupt func(file *encryptedFile)
+	}{
+		{"short nonce", func(file *encryptedFile) { file.Nonce = file.Nonce[:4] }},
+		{"missing nonce", func(file *encryptedFile) { file.Nonce = nil }},
+		{"short salt", func(file *encryptedFile) { file.Salt = file.Salt[:4] }},
+		{"few iterations", func(file *encryptedFile) { file.Iterations = 1 }},
+		{"too many iterations", func(file *encryptedFile) { file.Iterations = 1 << 30 }},
+		{"tampered data", func(file *encryptedFile) { file.Data[0] ^= 1 }},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var file encryptedFile
+			if err := json.Unmarshal(raw, &file); err != nil {
+				t.Fatal(err)
+			}
+			test.corrupt(&file)
+			corrupted, _ := json.Marshal(file)
+			if err := ioutil.WriteFile(creds.Path, corrupted, 0600); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := store.Load("test"); err == nil {
+				t.Error("Load() of corrupted file succeeded")
+			}
+		})
+	}
+}
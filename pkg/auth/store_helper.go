@@ -0,0 +1,105 @@
+package auth
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// helperPrefix is prepended to helper names which are not paths, e.g.
+// helper "pass" runs executable "go-hastily-credential-pass".
+const helperPrefix = "go-hastily-credential-"
+
+// helperStore delegates credentials to an external program, similar to git
+// credential helpers. The helper is called with one of "get", "store" or "erase"
+// and exchanges "key=value" lines over stdin and stdout. Every request contains
+// "context=<name>"; "store" requests and "get" responses additionally contain
+// access_token, refresh_token, id_token, token_type, endpoint and username.
+type helperStore struct {
+	Helper string
+}
+
+// Load asks helper for credentials of a context. Helper reports missing
+// credentials with an empty response, which is returned as a not-exist error
+// like the file stores do.
+func (store *helperStore) Load(context string) (*Credentials, error) {
+	out, err := store.run("get", map[string]string{"context": context})
+	if err != nil {
+		return nil, err
+	}
+	if out["access_token"] == "" && out["refresh_token"] == "" {
+		return nil, &os.PathError{Op: "load credentials of context", Path: context, Err: os.ErrNotExist}
+	}
+	return &Credentials{
+		AccessToken:  out["access_token"],
+		RefreshToken: out["refresh_token"],
+		TokenId:      out["id_token"],
+		Type:         out["token_type"],
+		Endpoint:     out["endpoint"],
+		Username:     out["username"],
+	}, nil
+}
+
+// Save passes credentials of a context to helper.
+func (store *helperStore) Save(context string, creds *Credentials) error {
+	_, err := store.run("store", map[string]string{
+		"context":       context,
+		"access_token":  creds.AccessToken,
+		"refresh_token": creds.RefreshToken,
+		"id_token":      creds.TokenId,
+		"token_type":    creds.Type,
+		"endpoint":      creds.Endpoint,
+		"username":      creds.Username,
+	})
+	return err
+}
+
+// Delete asks helper to erase credentials of a context.
+func (store *helperStore) Delete(context string) error {
+	_, err := store.run("erase", map[string]string{"context": context})
+	return err
+}
+
+// run executes helper action and parses its output.
+func (store *helperStore) run(action string, input map[string]string) (map[string]string, error) {
+	program := store.Helper
+	if !strings.ContainsRune(program, os.PathSeparator) {
+		program = helperPrefix + program
+	}
+
+	// write request
+	var stdin, stdout bytes.Buffer
+	for key, value := range input {
+		if strings.ContainsAny(value, "\n") {
+			return nil, fmt.Errorf("Credential field %s contains a newline.", key)
+		}
+		fmt.Fprintf(&stdin, "%s=%s\n", key, value)
+	}
+	stdin.WriteString("\n")
+
+	// run helper
+	cmd := exec.Command(program, action)
+	cmd.Stdin = &stdin
+	cmd.Stdout = &stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("Credential helper %s %s failed: %v", program, action, err)
+	}
+
+	// read response
+	output := make(map[string]string)
+	scanner := bufio.NewScanner(&stdout)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if line == "" {
+			break
+		}
+		if parts := strings.SplitN(line, "=", 2); len(parts) == 2 {
+			output[parts[0]] = parts[1]
+		}
+	}
+	return output, scanner.Err()
+}
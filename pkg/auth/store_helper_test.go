@@ -0,0 +1,57 @@
+package auth
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// testHelper keeps credentials of a single context in a file next to it.
+const testHelper = `#!/bin/sh
+file="$(dirname "$0")/credentials"
+case "$1" in
+get) [ -f "$file" ] && cat "$file" ;;
+store) cat > "$file" ;;
+erase) rm -f "$file" ;;
+esac
+exit 0
+`
+
+func TestHelperStoreRoundTrip(t *testing.T) {
+	dir, err := ioutil.TempDir("", "auth")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	helper := filepath.Join(dir, "helper")
+	if err = ioutil.WriteFile(helper, []byte(testHelper), 0700); err != nil {
+		t.Fatal(err)
+	}
+	store := &helperStore{Helper: helper}
+
+	// missing credentials
+	if _, err = store.Load("prod"); !os.IsNotExist(err) {
+		t.Fatalf("Load() before Save() error = %v, want not exist", err)
+	}
+
+	// stored credentials
+	if err = store.Save("prod", &Credentials{AccessToken: "access", Username: "user"}); err != nil {
+		t.Fatal(err)
+	}
+	creds, err := store.Load("prod")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if creds.AccessToken != "access" || creds.Username != "user" {
+		t.Errorf("Load() = %+v, want saved credentials", creds)
+	}
+
+	// erased credentials
+	if err = store.Delete("prod"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = store.Load("prod"); !os.IsNotExist(err) {
+		t.Errorf("Load() after Delete() error = %v, want not exist", err)
+	}
+}
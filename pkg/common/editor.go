@@ -0,0 +1,26 @@
+package common
+
+import (
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// defaultEditor is used when $EDITOR is not set.
+const defaultEditor = "vi"
+
+// EditFile opens file in user's $EDITOR and waits for it to exit.
+func EditFile(path string) error {
+	editor := os.Getenv("EDITOR")
+	if strings.TrimSpace(editor) == "" {
+		editor = defaultEditor
+	}
+
+	// editor might contain args, e.g. "code --wait"
+	args := append(strings.Fields(editor), path)
+	cmd := exec.Command(args[0], args[1:]...)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	return cmd.Run()
+}
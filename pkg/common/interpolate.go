@@ -0,0 +1,127 @@
+package common
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// interpolation matches "${...}" references and "$${" escapes.
+var interpolation = regexp.MustCompile(`\$\$\{|\$\{([^}]*)\}`)
+
+// Interpolate replaces references in a string value with their values:
+//
+//	${VAR}          value of environment variable VAR, which must be set
+//	${VAR:-default} value of VAR, or default if VAR is unset or empty
+//	${file:/path}   contents of file without trailing newline
+//
+// Use "$${" to write a literal "${".
+func Interpolate(value string) (string, error) {
+	var firstErr error
+	result := interpolation.ReplaceAllStringFunc(value, func(match string) string {
+		if match == "$${" {
+			return "${"
+		}
+		resolved, err := resolveReference(match[2 : len(match)-1])
+		if err != nil && firstErr == nil {
+			firstErr = err
+		}
+		return resolved
+	})
+	if firstErr != nil {
+		return "", firstErr
+	}
+	return result, nil
+}
+
+// InterpolateValue interpolates all strings of a decoded yaml or json value
+// in place. Keys and other scalars are left as they are.
+func InterpolateValue(value interface{}) (interface{}, error) {
+	switch typed := value.(type) {
+	case string:
+		return Interpolate(typed)
+	case map[string]interface{}:
+		for key, val := range typed {
+			resolved, err := InterpolateValue(val)
+			if err != nil {
+				return nil, err
+			}
+			typed[key] = resolved
+		}
+	case map[interface{}]interface{}:
+		for key, val := range typed {
+			resolved, err := InterpolateValue(val)
+			if err != nil {
+				return nil, err
+			}
+			typed[key] = resolved
+		}
+	case []interface{}:
+		for i := range typed {
+			resolved, err := InterpolateValue(typed[i])
+			if err != nil {
+				return nil, err
+			}
+			typed[i] = resolved
+		}
+	}
+	return value, nil
+}
+
+// InterpolateJSON interpolates all strings of a json document. Resolved
+// values are encoded as json strings, so they can hold any character.
+func InterpolateJSON(data []byte) ([]byte, error) {
+	if !bytes.Contains(data, []byte("${")) {
+		return data, nil
+	}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var value interface{}
+	if err := decoder.Decode(&value); err != nil {
+		return nil, err
+	}
+	value, err := InterpolateValue(value)
+	if err != nil {
+		return nil, err
+	}
+
+	var out bytes.Buffer
+	encoder := json.NewEncoder(&out)
+	encoder.SetEscapeHTML(false)
+	if err = encoder.Encode(value); err != nil {
+		return nil, err
+	}
+	return bytes.TrimSpace(out.Bytes()), nil
+}
+
+// resolveReference returns value of a single reference.
+func resolveReference(ref string) (string, error) {
+
+	// file contents
+	if strings.HasPrefix(ref, "file:") {
+		data, err := ioutil.ReadFile(strings.TrimPrefix(ref, "file:"))
+		if err != nil {
+			return "", fmt.Errorf("Unable to interpolate ${%s}: %v", ref, err)
+		}
+		return strings.TrimRight(string(data), "\r\n"), nil
+	}
+
+	// variable with default
+	if parts := strings.SplitN(ref, ":-", 2); len(parts) == 2 {
+		if value := os.Getenv(parts[0]); value != "" {
+			return value, nil
+		}
+		return parts[1], nil
+	}
+
+	// required variable
+	value, ok := os.LookupEnv(ref)
+	if !ok {
+		return "", fmt.Errorf("Unable to interpolate ${%s}: environment variable is not set.", ref)
+	}
+	return value, nil
+}
@@ -0,0 +1,77 @@
+package common
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestInterpolate(t *testing.T) {
+	dir, err := ioutil.TempDir("", "interpolate")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	secret := filepath.Join(dir, "secret")
+	if err = ioutil.WriteFile(secret, []byte("line: \"one\"\nline two\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	os.Setenv("INTERPOLATE_SET", "value")
+	os.Setenv("INTERPOLATE_EMPTY", "")
+	os.Unsetenv("INTERPOLATE_UNSET")
+
+	tests := []struct {
+		name    string
+		input   string
+		want    string
+		wantErr bool
+	}{
+		{"plain", "no references", "no references", false},
+		{"variable", "a ${INTERPOLATE_SET} b", "a value b", false},
+		{"default unused", "${INTERPOLATE_SET:-other}", "value", false},
+		{"default for unset", "${INTERPOLATE_UNSET:-other}", "other", false},
+		{"default for empty", "${INTERPOLATE_EMPTY:-other}", "other", false},
+		{"default with separators", "${INTERPOLATE_UNSET:-a: b # c}", "a: b # c", false},
+		{"file", "${file:" + secret + "}", "line: \"one\"\nline two", false},
+		{"escape", "$${INTERPOLATE_SET}", "${INTERPOLATE_SET}", false},
+		{"unset", "${INTERPOLATE_UNSET}", "", true},
+		{"missing file", "${file:" + filepath.Join(dir, "missing") + "}", "", true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := Interpolate(test.input)
+			if (err != nil) != test.wantErr {
+				t.Fatalf("Interpolate(%q) error = %v, want error %v", test.input, err, test.wantErr)
+			}
+			if got != test.want {
+				t.Errorf("Interpolate(%q) = %q, want %q", test.input, got, test.want)
+			}
+		})
+	}
+}
+
+func TestInterpolateJSON(t *testing.T) {
+	os.Setenv("INTERPOLATE_QUOTED", `say "hi" <b>`)
+
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{"no references", `{"id": 1}`, `{"id": 1}`},
+		{"nested strings", `{"a":{"b":["${INTERPOLATE_QUOTED}"]},"id":12345678901234567890}`, `{"a":{"b":["say \"hi\" <b>"]},"id":12345678901234567890}`},
+		{"keys are kept", `{"${INTERPOLATE_QUOTED}":"x"}`, `{"${INTERPOLATE_QUOTED}":"x"}`},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := InterpolateJSON([]byte(test.input))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(got) != test.want {
+				t.Errorf("InterpolateJSON(%s) = %s, want %s", test.input, got, test.want)
+			}
+		})
+	}
+}
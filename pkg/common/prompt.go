@@ -0,0 +1,150 @@
+package common
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+
+	. "github.com/fhivemind/go-hastily/pkg/global"
+	"github.com/manifoldco/promptui"
+	"golang.org/x/term"
+)
+
+// IsInteractive checks if stdin is a terminal, i.e. prompts can be answered.
+func IsInteractive() bool {
+	return term.IsTerminal(int(os.Stdin.Fd()))
+}
+
+// Confirm asks user a yes/no question. Returns false if user declined.
+func Confirm(label string) (bool, error) {
+	prompt := promptui.Prompt{
+		Label:     label,
+		IsConfirm: true,
+	}
+	_, err := prompt.Run()
+	if err == promptui.ErrAbort {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// PromptText asks user for a non-empty value.
+func PromptText(label string, def string) (string, error) {
+	prompt := promptui.Prompt{
+		Label:   label,
+		Default: def,
+		Validate: func(input string) error {
+			if strings.TrimSpace(input) == "" {
+				return errors.New("value is required")
+			}
+			return nil
+		},
+	}
+	return prompt.Run()
+}
+
+// PromptPassword asks user for a value without echoing it.
+func PromptPassword(label string) (string, error) {
+	prompt := promptui.Prompt{
+		Label: label,
+		Mask:  '*',
+	}
+	return prompt.Run()
+}
+
+// PromptStruct asks user for values of all exported fields of a struct pointer.
+// Prompts are type-aware, and fields of composite types are entered as json.
+// Empty input keeps the current value.
+func PromptStruct(object interface{}) error {
+	v := reflect.ValueOf(object)
+	if v.Kind() != reflect.Ptr || v.Elem().Kind() != reflect.Struct {
+		return errors.New("PromptStruct expects a pointer to struct")
+	}
+	v = v.Elem()
+
+	for i := 0; i < v.NumField(); i++ {
+		field := v.Field(i)
+		if !field.CanSet() {
+			continue
+		}
+
+		// prompt for valid value
+		prompt := promptui.Prompt{
+			Label: fmt.Sprintf("%s (%s)", fieldName(v.Type().Field(i)), field.Type()),
+			Validate: func(input string) error {
+				if input == "" {
+					return nil
+				}
+				return setFromString(reflect.New(field.Type()).Elem(), input)
+			},
+		}
+		if !IsZero(field.Interface()) {
+			prompt.Default = fmt.Sprintf("%v", field.Interface())
+		}
+		input, err := prompt.Run()
+		if err != nil {
+			return err
+		}
+
+		// update field
+		if input != "" {
+			if err := setFromString(field, input); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// fieldName returns json name of struct field.
+func fieldName(field reflect.StructField) string {
+	if tag := strings.Split(field.Tag.Get("json"), ",")[0]; tag != "" && tag != "-" {
+		return tag
+	}
+	return field.Name
+}
+
+// setFromString parses input based on value type and sets it.
+func setFromString(v reflect.Value, input string) error {
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(input)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(input)
+		if err != nil {
+			return errors.New("expected true or false")
+		}
+		v.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(input, 10, v.Type().Bits())
+		if err != nil {
+			return errors.New("expected an integer")
+		}
+		v.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(input, 10, v.Type().Bits())
+		if err != nil {
+			return errors.New("expected a positive integer")
+		}
+		v.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(input, v.Type().Bits())
+		if err != nil {
+			return errors.New("expected a number")
+		}
+		v.SetFloat(f)
+	default:
+		if err := json.Unmarshal([]byte(input), v.Addr().Interface()); err != nil {
+			return fmt.Errorf("expected json: %v", err)
+		}
+	}
+	return nil
+}
@@ -1,6 +1,9 @@
 package common
 
 import (
+	"fmt"
+	"strings"
+
 	"github.com/olekukonko/tablewriter"
 )
 
@@ -37,6 +40,23 @@ func (ttype TableType) String() string {
 	return [...]string{"CSV", "Markdown", "Preview", "Basic"}[ttype]
 }
 
+// ParseTableType converts format name to TableType.
+func ParseTableType(name string) (TableType, error) {
+	switch strings.ToLower(name) {
+	case "csv":
+		return csv, nil
+	case "markdown", "md":
+		return markdown, nil
+	case "preview":
+		return preview, nil
+	case "basic", "":
+		return basic, nil
+	case "vertical":
+		return vertical, nil
+	}
+	return basic, fmt.Errorf("Unknown output format %q.", name)
+}
+
 // SetStyleForTable configures table style based on type.
 func (ttype TableType) SetStyleForTable(table *tablewriter.Table, size int) {
 	switch ttype {
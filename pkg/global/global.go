@@ -15,7 +15,7 @@ func HandleError(err error) {
 		} else {
 			CLI.Error("Something went wrong.")
 		}
-		os.Exit(1)
+		Exit(1)
 	}
 }
 
@@ -23,10 +23,33 @@ func HandleError(err error) {
 func HandleErrorMessage(err string) {
 	if err != "" {
 		CLI.Error(err)
-		os.Exit(1)
+		Exit(1)
 	}
 }
 
+// exitHooks run before the CLI exits, e.g. to write recordings.
+var exitHooks []func()
+
+// OnExit registers function which runs before the CLI exits.
+func OnExit(hook func()) {
+	exitHooks = append(exitHooks, hook)
+}
+
+// RunExitHooks runs registered exit hooks once, in reverse order.
+func RunExitHooks() {
+	hooks := exitHooks
+	exitHooks = nil
+	for i := len(hooks) - 1; i >= 0; i-- {
+		hooks[i]()
+	}
+}
+
+// Exit runs exit hooks and terminates the CLI with status code.
+func Exit(code int) {
+	RunExitHooks()
+	os.Exit(code)
+}
+
 // IsZero checks if values are uninitialized.
 func IsZero(x interface{}) bool {
 	return reflect.DeepEqual(x, reflect.Zero(reflect.TypeOf(x)).Interface())
@@ -0,0 +1,391 @@
+// Package mock implements an in-memory REST backend which serves CRUD requests
+// for any model, so that the CLI can be developed and tested without a real backend.
+package mock
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/imdario/mergo"
+)
+
+// Object is a single stored object.
+type Object map[string]interface{}
+
+// Options configures mock server.
+type Options struct {
+	// LoginPath serves password grant token requests.
+	LoginPath string
+	// VerifyPath serves credentials verification requests.
+	VerifyPath string
+	// DryRunParam and DryRunHeader mark requests which must not change data.
+	DryRunParam  string
+	DryRunHeader string
+	// Latency is added to every response.
+	Latency time.Duration
+	// ErrorRate is the share of requests, from 0 to 1, failing with 500.
+	ErrorRate float64
+}
+
+// Server is an in-memory CRUD backend. Collections are created on first use
+// and named by the last path segment, e.g. /api/users/1 belongs to "users".
+type Server struct {
+	opts        Options
+	mutex       sync.Mutex
+	collections map[string]map[int]Object
+	random      *rand.Rand
+}
+
+// NewServer creates an empty mock server.
+func NewServer(opts Options) *Server {
+	if opts.DryRunParam == "" {
+		opts.DryRunParam = "dryRun"
+	}
+	return &Server{
+		opts:        opts,
+		collections: make(map[string]map[int]Object),
+		random:      rand.New(rand.NewSource(time.Now().UnixNano())),
+	}
+}
+
+// Seed adds objects to model collection. Objects without ID get the next free one.
+func (server *Server) Seed(model string, objects []Object) {
+	server.mutex.Lock()
+	defer server.mutex.Unlock()
+
+	for _, object := range objects {
+		server.insert(model, object)
+	}
+}
+
+// ServeHTTP routes requests to login, verify and collection handlers.
+func (server *Server) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	if server.opts.Latency > 0 {
+		time.Sleep(server.opts.Latency)
+	}
+	if server.opts.ErrorRate > 0 && server.chance() < server.opts.ErrorRate {
+		writeError(w, http.StatusInternalServerError, "Injected error.")
+		return
+	}
+
+	path := strings.TrimSuffix(req.URL.Path, "/")
+	switch {
+	case server.opts.LoginPath != "" && path == strings.TrimSuffix(server.opts.LoginPath, "/"):
+		server.login(w, req)
+		return
+	case server.opts.VerifyPath != "" && path == strings.TrimSuffix(server.opts.VerifyPath, "/"):
+		writeJSON(w, http.StatusOK, []Object{{"id": "1", "email": "mock@example.com"}})
+		return
+	}
+
+	// split into model and optional id
+	segments := strings.Split(strings.Trim(path, "/"), "/")
+	model, id := segments[len(segments)-1], 0
+	if n, err := strconv.Atoi(model); err == nil && len(segments) > 1 {
+		model, id = segments[len(segments)-2], n
+	}
+	if model == "" {
+		writeError(w, http.StatusNotFound, "No model in path.")
+		return
+	}
+
+	switch {
+	case req.Method == http.MethodGet && id == 0:
+		server.list(w, req, model)
+	case req.Method == http.MethodGet:
+		server.get(w, req, model, id)
+	case req.Method == http.MethodPost && id == 0:
+		server.create(w, req, model)
+	case req.Method == http.MethodPut && id != 0:
+		server.update(w, req, model, id, false)
+	case req.Method == http.MethodPatch && id != 0:
+		server.update(w, req, model, id, true)
+	case req.Method == http.MethodDelete && id != 0:
+		server.delete(w, req, model, id)
+	default:
+		writeError(w, http.StatusMethodNotAllowed, fmt.Sprintf("Method %s is not allowed on %s.", req.Method, req.URL.Path))
+	}
+}
+
+// login issues a token for any username and password.
+func (server *Server) login(w http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodPost {
+		writeError(w, http.StatusMethodNotAllowed, "Login requires POST.")
+		return
+	}
+	if err := req.ParseForm(); err != nil || req.PostForm.Get("username") == "" {
+		writeJSON(w, http.StatusBadRequest, Object{"error": "invalid_request", "error_description": "Username is required."})
+		return
+	}
+	writeJSON(w, http.StatusOK, Object{
+		"access_token":  "mock-access-token",
+		"refresh_token": "mock-refresh-token",
+		"token_type":    "Bearer",
+		"expires_in":    3600,
+	})
+}
+
+// list serves filtered and paginated collection. Query params other than
+// page, per_page and dry-run param filter by equal field values.
+func (server *Server) list(w http.ResponseWriter, req *http.Request, model string) {
+	server.mutex.Lock()
+	defer server.mutex.Unlock()
+
+	query := req.URL.Query()
+	var objects []Object
+	for _, id := range server.ids(model) {
+		object := server.collections[model][id]
+		if matches(object, query, server.opts.DryRunParam) {
+			objects = append(objects, object)
+		}
+	}
+	total := len(objects)
+
+	// paginate
+	if query.Get("page") != "" || query.Get("per_page") != "" {
+		page, perPage := atoiOr(query.Get("page"), 1), atoiOr(query.Get("per_page"), 10)
+		start := (page - 1) * perPage
+		if start > total {
+			start = total
+		}
+		end := start + perPage
+		if end > total {
+			end = total
+		}
+		objects = objects[start:end]
+		w.Header().Set("X-Page", strconv.Itoa(page))
+		w.Header().Set("X-Per-Page", strconv.Itoa(perPage))
+	}
+	w.Header().Set("X-Total-Count", strconv.Itoa(total))
+
+	if objects == nil {
+		objects = []Object{}
+	}
+	writeCacheable(w, req, objects)
+}
+
+// get serves a single object.
+func (server *Server) get(w http.ResponseWriter, req *http.Request, model string, id int) {
+	server.mutex.Lock()
+	defer server.mutex.Unlock()
+
+	object, ok := server.collections[model][id]
+	if !ok {
+		writeNotFound(w, model, id)
+		return
+	}
+	writeCacheable(w, req, object)
+}
+
+// create stores a new object.
+func (server *Server) create(w http.ResponseWriter, req *http.Request, model string) {
+	object, ok := readObject(w, req)
+	if !ok {
+		return
+	}
+
+	server.mutex.Lock()
+	defer server.mutex.Unlock()
+
+	if id := objectID(object); id != 0 {
+		if _, exists := server.collections[model][id]; exists {
+			writeError(w, http.StatusConflict, fmt.Sprintf("Object %s/%d already exists.", model, id))
+			return
+		}
+	}
+	if server.isDryRun(req) {
+		writeJSON(w, http.StatusOK, object)
+		return
+	}
+	writeJSON(w, http.StatusOK, server.insert(model, object))
+}
+
+// update replaces or, with merge, patches an existing object.
+func (server *Server) update(w http.ResponseWriter, req *http.Request, model string, id int, merge bool) {
+	object, ok := readObject(w, req)
+	if !ok {
+		return
+	}
+
+	server.mutex.Lock()
+	defer server.mutex.Unlock()
+
+	existing, exists := server.collections[model][id]
+	if !exists {
+		writeNotFound(w, model, id)
+		return
+	}
+	if merge {
+		updated := Object{}
+		for key, value := range existing {
+			updated[key] = value
+		}
+		if err := mergo.Merge(&updated, object, mergo.WithOverride); err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		object = updated
+	}
+	object["id"] = id
+
+	if !server.isDryRun(req) {
+		server.collections[model][id] = object
+	}
+	writeJSON(w, http.StatusOK, object)
+}
+
+// delete removes an object.
+func (server *Server) delete(w http.ResponseWriter, req *http.Request, model string, id int) {
+	server.mutex.Lock()
+	defer server.mutex.Unlock()
+
+	if _, exists := server.collections[model][id]; !exists {
+		writeNotFound(w, model, id)
+		return
+	}
+	if !server.isDryRun(req) {
+		delete(server.collections[model], id)
+	}
+	writeJSON(w, http.StatusOK, Object{})
+}
+
+// insert stores object, assigning the next free ID if it has none.
+func (server *Server) insert(model string, object Object) Object {
+	collection, ok := server.collections[model]
+	if !ok {
+		collection = make(map[int]Object)
+		server.collections[model] = collection
+	}
+
+	id := objectID(object)
+	if id == 0 {
+		for existing := range collection {
+			if existing > id {
+				id = existing
+			}
+		}
+		id++
+	}
+	object["id"] = id
+	collection[id] = object
+	return object
+}
+
+// ids returns sorted IDs of model collection.
+func (server *Server) ids(model string) []int {
+	var ids []int
+	for id := range server.collections[model] {
+		ids = append(ids, id)
+	}
+	sort.Ints(ids)
+	return ids
+}
+
+// isDryRun checks if request was marked with server dry-run.
+func (server *Server) isDryRun(req *http.Request) bool {
+	if server.opts.DryRunHeader != "" && req.Header.Get(server.opts.DryRunHeader) != "" {
+		return true
+	}
+	return req.URL.Query().Get(server.opts.DryRunParam) == "true"
+}
+
+// chance returns a random number between 0 and 1.
+func (server *Server) chance() float64 {
+	server.mutex.Lock()
+	defer server.mutex.Unlock()
+	return server.random.Float64()
+}
+
+// matches checks if object has all field values given in query.
+func matches(object Object, query url.Values, dryRunParam string) bool {
+	for key, values := range query {
+		if key == "page" || key == "per_page" || key == dryRunParam {
+			continue
+		}
+		if fmt.Sprintf("%v", object[key]) != values[0] {
+			return false
+		}
+	}
+	return true
+}
+
+// readObject decodes JSON object from request body.
+func readObject(w http.ResponseWriter, req *http.Request) (Object, bool) {
+	object := Object{}
+	if err := json.NewDecoder(req.Body).Decode(&object); err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("Invalid JSON body: %v", err))
+		return nil, false
+	}
+	return object, true
+}
+
+// objectID returns numeric ID of object, or zero.
+func objectID(object Object) int {
+	switch id := object["id"].(type) {
+	case float64:
+		return int(id)
+	case int:
+		return id
+	case string:
+		n, _ := strconv.Atoi(id)
+		return n
+	}
+	return 0
+}
+
+// atoiOr parses positive integer, or returns def.
+func atoiOr(value string, def int) int {
+	n, err := strconv.Atoi(value)
+	if err != nil || n < 1 {
+		return def
+	}
+	return n
+}
+
+// writeJSON writes JSON response.
+func writeJSON(w http.ResponseWriter, status int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(body)
+}
+
+// writeCacheable writes JSON response with ETag, or 304 if client already
+// holds the same version.
+func writeCacheable(w http.ResponseWriter, req *http.Request, body interface{}) {
+	byt, err := json.Marshal(body)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	sum := sha256.Sum256(byt)
+	etag := `"` + hex.EncodeToString(sum[:8]) + `"`
+	w.Header().Set("ETag", etag)
+	w.Header().Set("Cache-Control", "no-cache")
+	if req.Header.Get("If-None-Match") == etag {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(append(byt, '\n'))
+}
+
+// writeError writes JSON error response.
+func writeError(w http.ResponseWriter, status int, message string) {
+	writeJSON(w, status, Object{"error": message})
+}
+
+// writeNotFound writes response for missing object.
+func writeNotFound(w http.ResponseWriter, model string, id int) {
+	writeError(w, http.StatusNotFound, fmt.Sprintf("Object %s/%d not found.", model, id))
+}
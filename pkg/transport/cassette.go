@@ -0,0 +1,193 @@
+package transport
+
+// This file records HTTP exchanges of shared clients into a cassette directory
+// and replays them later without network access, e.g. for deterministic tests
+// and offline demos.
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// interaction is a recorded request/response pair as saved in cassette.
+type interaction struct {
+	Request struct {
+		Method string `json:"method"`
+		URL    string `json:"url"`
+		Body   string `json:"body,omitempty"`
+	} `json:"request"`
+	Response struct {
+		Status int         `json:"status"`
+		Header http.Header `json:"header"`
+		Body   string      `json:"body"`
+	} `json:"response"`
+}
+
+// cassette handles interactions saved in a directory. Repeated requests
+// with the same key are saved in sequence and replayed in the same order.
+type cassette struct {
+	dir     string
+	next    http.RoundTripper
+	mutex   *sync.Mutex
+	counter map[string]int
+}
+
+// Record returns middleware which saves every exchange into cassette directory.
+func Record(dir string) Middleware {
+	mutex, counter := &sync.Mutex{}, make(map[string]int)
+	return func(next http.RoundTripper) http.RoundTripper {
+		return &cassetteRecorder{cassette{dir: dir, next: next, mutex: mutex, counter: counter}}
+	}
+}
+
+// Replay returns middleware which serves exchanges from cassette directory
+// without network access. Requests which were not recorded fail.
+func Replay(dir string) Middleware {
+	mutex, counter := &sync.Mutex{}, make(map[string]int)
+	return func(next http.RoundTripper) http.RoundTripper {
+		return &cassettePlayer{cassette{dir: dir, next: next, mutex: mutex, counter: counter}}
+	}
+}
+
+// cassetteRecorder sends requests and saves exchanges.
+type cassetteRecorder struct {
+	cassette
+}
+
+// RoundTrip sends request and saves the exchange.
+func (recorder *cassetteRecorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	record, err := newInteraction(req)
+	if err != nil {
+		return nil, err
+	}
+
+	// send
+	resp, err := recorder.next.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	body, err := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = ioutil.NopCloser(bytes.NewReader(body))
+
+	// save with credentials hidden, as cassettes are meant to be committed
+	record.Response.Status = resp.StatusCode
+	record.Response.Header = RedactHeader(resp.Header)
+	record.Response.Header.Del("Content-Length")
+	record.Response.Body = RedactBody(body, resp.Header.Get("Content-Type"))
+	byt, err := json.MarshalIndent(record, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	if err = os.MkdirAll(recorder.dir, 0755); err != nil {
+		return nil, err
+	}
+	if err = ioutil.WriteFile(recorder.path(record), byt, 0600); err != nil {
+		return nil, err
+	}
+
+	return resp, nil
+}
+
+// cassettePlayer serves saved exchanges.
+type cassettePlayer struct {
+	cassette
+}
+
+// RoundTrip serves response recorded for request. The last recorded
+// response is repeated once the sequence is exhausted.
+func (player *cassettePlayer) RoundTrip(req *http.Request) (*http.Response, error) {
+	record, err := newInteraction(req)
+	if err != nil {
+		return nil, err
+	}
+
+	// find recording
+	path := player.lookup(record)
+	byt, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, fmt.Errorf("No recorded response for %s %s in cassette %s.", record.Request.Method, record.Request.URL, player.dir)
+	}
+	if err != nil {
+		return nil, err
+	}
+	var saved interaction
+	if err = json.Unmarshal(byt, &saved); err != nil {
+		return nil, fmt.Errorf("Invalid cassette file %s: %v", path, err)
+	}
+
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", saved.Response.Status, http.StatusText(saved.Response.Status)),
+		StatusCode:    saved.Response.Status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        saved.Response.Header,
+		Body:          ioutil.NopCloser(bytes.NewBufferString(saved.Response.Body)),
+		ContentLength: int64(len(saved.Response.Body)),
+		Request:       req,
+	}, nil
+}
+
+// lookup returns file of the next recorded interaction with the same key,
+// or the last one if the sequence is exhausted.
+func (player *cassettePlayer) lookup(record *interaction) string {
+	player.mutex.Lock()
+	defer player.mutex.Unlock()
+
+	k := key(record)
+	n := player.counter[k] + 1
+	path := filepath.Join(player.dir, fmt.Sprintf("%s-%d.json", k, n))
+	if _, err := os.Stat(path); err == nil || n == 1 {
+		player.counter[k] = n
+		return path
+	}
+	return filepath.Join(player.dir, fmt.Sprintf("%s-%d.json", k, n-1))
+}
+
+// path returns file of the next interaction with the same key.
+func (c *cassette) path(record *interaction) string {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	k := key(record)
+	c.counter[k]++
+	return filepath.Join(c.dir, fmt.Sprintf("%s-%d.json", k, c.counter[k]))
+}
+
+// newInteraction creates interaction from request. URL and body are
+// normalized and redacted, so that secrets neither leak into cassettes
+// nor affect matching.
+func newInteraction(req *http.Request) (*interaction, error) {
+	record := &interaction{}
+	record.Request.Method = req.Method
+	record.Request.URL = RedactURL(req.URL)
+
+	if req.Body != nil {
+		body, err := ioutil.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		req.Body = ioutil.NopCloser(bytes.NewReader(body))
+		record.Request.Body = RedactBody(body, req.Header.Get("Content-Type"))
+	}
+	return record, nil
+}
+
+// key identifies request by method, URL and normalized body.
+func key(record *interaction) string {
+	sum := sha256.Sum256([]byte(record.Request.Method + " " + record.Request.URL + "\n" + record.Request.Body))
+	return hex.EncodeToString(sum[:8])
+}
@@ -0,0 +1,89 @@
+package transport
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCassetteReplay(t *testing.T) {
+	dir, err := ioutil.TempDir("", "cassette")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	// backend answers with a new version on every request
+	hits := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		hits++
+		w.Header().Set("Set-Cookie", "sid=secret")
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"version":%d,"token":"secret"}`, hits)
+	}))
+
+	// record
+	recorder := &http.Client{Transport: Record(dir)(http.DefaultTransport)}
+	for i := 0; i < 2; i++ {
+		get(t, recorder, server.URL+"/users?access_token=secret")
+	}
+	post(t, recorder, server.URL+"/users", `{"name":"a"}`)
+	server.Close()
+
+	// recordings hold no secrets
+	files, _ := filepath.Glob(filepath.Join(dir, "*.json"))
+	if len(files) != 3 {
+		t.Fatalf("recorded %d files, want 3", len(files))
+	}
+	for _, file := range files {
+		byt, _ := ioutil.ReadFile(file)
+		if strings.Contains(string(byt), "secret") {
+			t.Errorf("cassette file %s leaks a secret:\n%s", file, byt)
+		}
+	}
+
+	// replay in recorded order without network, repeating the last response
+	player := &http.Client{Transport: Replay(dir)(http.DefaultTransport)}
+	for _, want := range []string{`"version":1`, `"version":2`, `"version":2`} {
+		if body := get(t, player, server.URL+"/users?access_token=other"); !strings.Contains(body, want) {
+			t.Errorf("replayed body %s, want %s", body, want)
+		}
+	}
+	if body := post(t, player, server.URL+"/users", `{ "name": "a" }`); !strings.Contains(body, `"version":3`) {
+		t.Errorf("replayed body %s, want version 3", body)
+	}
+
+	// requests which were not recorded fail
+	if _, err := player.Get(server.URL + "/groups"); err == nil || !strings.Contains(err.Error(), "No recorded response") {
+		t.Errorf("unrecorded request error = %v, want no recorded response", err)
+	}
+}
+
+// get sends GET request and returns response body.
+func get(t *testing.T, client *http.Client, url string) string {
+	t.Helper()
+	resp, err := client.Get(url)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	body, _ := ioutil.ReadAll(resp.Body)
+	return string(body)
+}
+
+// post sends POST request with JSON body and returns response body.
+func post(t *testing.T, client *http.Client, url string, body string) string {
+	t.Helper()
+	resp, err := client.Post(url, "application/json", strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	byt, _ := ioutil.ReadAll(resp.Body)
+	return string(byt)
+}
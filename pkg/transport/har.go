@@ -0,0 +1,258 @@
+package transport
+
+// This file records HTTP exchanges of shared clients into HAR 1.2 files
+// which can be opened in browser devtools. Credentials are redacted.
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/fhivemind/go-hastily/pkg/version"
+)
+
+// harLog is the root object of HAR file.
+type harLog struct {
+	Log struct {
+		Version string     `json:"version"`
+		Creator harCreator `json:"creator"`
+		Entries []harEntry `json:"entries"`
+	} `json:"log"`
+}
+
+// harCreator describes application which created HAR file.
+type harCreator struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+}
+
+// harEntry holds a single request/response exchange.
+type harEntry struct {
+	StartedDateTime string      `json:"startedDateTime"`
+	Time            float64     `json:"time"`
+	Request         harRequest  `json:"request"`
+	Response        harResponse `json:"response"`
+	Cache           struct{}    `json:"cache"`
+	Timings         harTimings  `json:"timings"`
+	Comment         string      `json:"comment,omitempty"`
+}
+
+// harRequest describes recorded request.
+type harRequest struct {
+	Method      string         `json:"method"`
+	URL         string         `json:"url"`
+	HTTPVersion string         `json:"httpVersion"`
+	Cookies     []harNameValue `json:"cookies"`
+	Headers     []harNameValue `json:"headers"`
+	QueryString []harNameValue `json:"queryString"`
+	PostData    *harPostData   `json:"postData,omitempty"`
+	HeadersSize int            `json:"headersSize"`
+	BodySize    int            `json:"bodySize"`
+}
+
+// harResponse describes recorded response.
+type harResponse struct {
+	Status      int            `json:"status"`
+	StatusText  string         `json:"statusText"`
+	HTTPVersion string         `json:"httpVersion"`
+	Cookies     []harNameValue `json:"cookies"`
+	Headers     []harNameValue `json:"headers"`
+	Content     harContent     `json:"content"`
+	RedirectURL string         `json:"redirectURL"`
+	HeadersSize int            `json:"headersSize"`
+	BodySize    int            `json:"bodySize"`
+}
+
+// harNameValue holds a header or query param.
+type harNameValue struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+// harPostData holds request body.
+type harPostData struct {
+	MimeType string `json:"mimeType"`
+	Text     string `json:"text"`
+}
+
+// harContent holds response body.
+type harContent struct {
+	Size     int    `json:"size"`
+	MimeType string `json:"mimeType"`
+	Text     string `json:"text"`
+}
+
+// harTimings holds durations of exchange phases in milliseconds.
+type harTimings struct {
+	Send    float64 `json:"send"`
+	Wait    float64 `json:"wait"`
+	Receive float64 `json:"receive"`
+}
+
+// HARRecorder collects exchanges in memory and writes them into HAR file
+// when closed, so that recording does not slow down parallel requests.
+type HARRecorder struct {
+	path  string
+	mutex sync.Mutex
+	har   harLog
+}
+
+// harTransport records exchanges of a shared client.
+type harTransport struct {
+	recorder *HARRecorder
+	next     http.RoundTripper
+}
+
+// NewHARRecorder creates recorder which writes HAR file to path.
+func NewHARRecorder(path string) *HARRecorder {
+	recorder := &HARRecorder{path: path}
+	recorder.har.Log.Version = "1.2"
+	recorder.har.Log.Creator = harCreator{Name: "go-hastily", Version: version.Version}
+	recorder.har.Log.Entries = []harEntry{}
+	return recorder
+}
+
+// Middleware returns middleware which records every exchange.
+func (recorder *HARRecorder) Middleware() Middleware {
+	return func(next http.RoundTripper) http.RoundTripper {
+		return &harTransport{recorder: recorder, next: next}
+	}
+}
+
+// Close writes all recorded exchanges into HAR file.
+func (recorder *HARRecorder) Close() error {
+	recorder.mutex.Lock()
+	defer recorder.mutex.Unlock()
+
+	byt, err := json.MarshalIndent(&recorder.har, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err = os.MkdirAll(filepath.Dir(recorder.path), 0755); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(recorder.path, byt, 0600)
+}
+
+// add appends entry to recorded exchanges.
+func (recorder *HARRecorder) add(entry harEntry) {
+	recorder.mutex.Lock()
+	defer recorder.mutex.Unlock()
+	recorder.har.Log.Entries = append(recorder.har.Log.Entries, entry)
+}
+
+// RoundTrip sends request and records the exchange.
+func (har *harTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+
+	// keep request body
+	var reqBody []byte
+	if req.Body != nil {
+		body, err := ioutil.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		reqBody = body
+		req.Body = ioutil.NopCloser(bytes.NewReader(body))
+	}
+
+	// send
+	start := time.Now()
+	resp, err := har.next.RoundTrip(req)
+	wait := time.Since(start)
+	entry := harEntry{
+		StartedDateTime: start.Format(time.RFC3339Nano),
+		Request:         newHARRequest(req, reqBody),
+	}
+	if err != nil {
+		entry.Comment = err.Error()
+		entry.Response = harResponse{Cookies: []harNameValue{}, Headers: []harNameValue{}, HeadersSize: -1, BodySize: -1}
+		entry.Time = milliseconds(wait)
+		entry.Timings = harTimings{Wait: entry.Time}
+		har.recorder.add(entry)
+		return nil, err
+	}
+
+	// keep response body
+	respBody, err := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = ioutil.NopCloser(bytes.NewReader(respBody))
+	receive := time.Since(start) - wait
+
+	entry.Response = newHARResponse(resp, respBody)
+	entry.Timings = harTimings{Wait: milliseconds(wait), Receive: milliseconds(receive)}
+	entry.Time = entry.Timings.Wait + entry.Timings.Receive
+	har.recorder.add(entry)
+	return resp, nil
+}
+
+// newHARRequest converts request to HAR form.
+func newHARRequest(req *http.Request, body []byte) harRequest {
+	query := []harNameValue{}
+	for key, values := range redactValues(req.URL.Query()) {
+		for _, value := range values {
+			query = append(query, harNameValue{Name: key, Value: value})
+		}
+	}
+
+	ret := harRequest{
+		Method:      req.Method,
+		URL:         RedactURL(req.URL),
+		HTTPVersion: req.Proto,
+		Cookies:     []harNameValue{},
+		Headers:     harHeaders(req.Header),
+		QueryString: query,
+		HeadersSize: -1,
+		BodySize:    len(body),
+	}
+	if len(body) > 0 {
+		ret.PostData = &harPostData{
+			MimeType: req.Header.Get("Content-Type"),
+			Text:     RedactBody(body, req.Header.Get("Content-Type")),
+		}
+	}
+	return ret
+}
+
+// newHARResponse converts response to HAR form.
+func newHARResponse(resp *http.Response, body []byte) harResponse {
+	return harResponse{
+		Status:      resp.StatusCode,
+		StatusText:  http.StatusText(resp.StatusCode),
+		HTTPVersion: resp.Proto,
+		Cookies:     []harNameValue{},
+		Headers:     harHeaders(resp.Header),
+		Content: harContent{
+			Size:     len(body),
+			MimeType: resp.Header.Get("Content-Type"),
+			Text:     RedactBody(body, resp.Header.Get("Content-Type")),
+		},
+		RedirectURL: resp.Header.Get("Location"),
+		HeadersSize: -1,
+		BodySize:    len(body),
+	}
+}
+
+// harHeaders converts headers to HAR form with credentials hidden.
+func harHeaders(header http.Header) []harNameValue {
+	ret := []harNameValue{}
+	for key, values := range RedactHeader(header) {
+		for _, value := range values {
+			ret = append(ret, harNameValue{Name: key, Value: value})
+		}
+	}
+	return ret
+}
+
+// milliseconds converts duration to HAR time.
+func milliseconds(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
@@ -0,0 +1,117 @@
+package transport
+
+import (
+	"encoding/json"
+	"mime"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// Redacted replaces sensitive values in traces and recordings.
+const Redacted = "[REDACTED]"
+
+// sensitiveHeaders are always redacted.
+var sensitiveHeaders = []string{"Authorization", "Proxy-Authorization", "Cookie", "Set-Cookie", "X-Api-Key"}
+
+// sensitiveFields are body fields and query params which are always redacted.
+var sensitiveFields = []string{"password", "token", "access_token", "refresh_token", "id_token", "client_secret", "secret", "api_key"}
+
+// RedactHeader returns a copy of headers with credentials hidden.
+func RedactHeader(header http.Header) http.Header {
+	copied := make(http.Header, len(header))
+	for key, values := range header {
+		if isSensitiveHeader(key) {
+			copied[key] = []string{Redacted}
+			continue
+		}
+		copied[key] = append([]string(nil), values...)
+	}
+	return copied
+}
+
+// RedactURL formats URL with user info and sensitive query params hidden.
+func RedactURL(u *url.URL) string {
+	copied := *u
+	if copied.User != nil {
+		copied.User = url.User(Redacted)
+	}
+	if copied.RawQuery != "" {
+		copied.RawQuery = redactValues(copied.Query()).Encode()
+	}
+	return copied.String()
+}
+
+// RedactBody returns JSON or form body with sensitive fields hidden.
+// Other bodies are returned as is.
+func RedactBody(body []byte, contentType string) string {
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	if mediaType == "application/x-www-form-urlencoded" {
+		values, err := url.ParseQuery(string(body))
+		if err == nil {
+			return redactValues(values).Encode()
+		}
+	}
+
+	var data interface{}
+	if err := json.Unmarshal(body, &data); err != nil {
+		return string(body)
+	}
+	byt, err := json.Marshal(redactJSON(data))
+	if err != nil {
+		return string(body)
+	}
+	return string(byt)
+}
+
+// redactValues hides sensitive query params or form fields.
+func redactValues(values url.Values) url.Values {
+	for key := range values {
+		if isSensitiveField(key) || (envCfg.Auth.Query != "" && key == envCfg.Auth.Query) {
+			values.Set(key, Redacted)
+		}
+	}
+	return values
+}
+
+// redactJSON recursively hides sensitive fields of decoded JSON.
+func redactJSON(value interface{}) interface{} {
+	switch typed := value.(type) {
+	case map[string]interface{}:
+		for key, val := range typed {
+			if isSensitiveField(key) {
+				typed[key] = Redacted
+				continue
+			}
+			typed[key] = redactJSON(val)
+		}
+	case []interface{}:
+		for i := range typed {
+			typed[i] = redactJSON(typed[i])
+		}
+	}
+	return value
+}
+
+// isSensitiveHeader checks if header holds credentials.
+func isSensitiveHeader(key string) bool {
+	for _, name := range sensitiveHeaders {
+		if strings.EqualFold(key, name) {
+			return true
+		}
+	}
+	return envCfg.Auth.Header != "" && strings.EqualFold(key, envCfg.Auth.Header)
+}
+
+// isSensitiveField checks if body field or query param holds credentials.
+// Additional fields are configured with log.redact_fields.
+func isSensitiveField(key string) bool {
+	for _, names := range [][]string{sensitiveFields, envCfg.Log.RedactFields} {
+		for _, name := range names {
+			if strings.EqualFold(key, name) {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,244 @@
+// Package transport builds the HTTP client shared by the api and auth packages
+// so that TLS and connection settings of the current context apply to every request.
+package transport
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	cfg "github.com/fhivemind/go-hastily/config"
+	. "github.com/fhivemind/go-hastily/pkg/global"
+)
+
+// config loads environment configuration
+var envCfg = cfg.LoadConfig()
+
+var (
+	// clients caches shared clients per context.
+	clients = make(map[string]*http.Client)
+	// middlewares wrap transports of shared clients.
+	middlewares []Middleware
+	mutex       sync.Mutex
+)
+
+// Middleware wraps round trips of shared clients, e.g. to record exchanges.
+type Middleware func(next http.RoundTripper) http.RoundTripper
+
+// Defaults used when connection settings are not configured.
+const (
+	defaultTimeout               = 2 * time.Minute
+	defaultDialTimeout           = 10 * time.Second
+	defaultTLSHandshakeTimeout   = 10 * time.Second
+	defaultResponseHeaderTimeout = time.Minute
+	defaultMaxIdleConnsPerHost   = 32
+)
+
+// tlsVersions maps config values to TLS versions.
+var tlsVersions = map[string]uint16{
+	"1.0": tls.VersionTLS10,
+	"1.1": tls.VersionTLS11,
+	"1.2": tls.VersionTLS12,
+	"1.3": tls.VersionTLS13,
+}
+
+// Client returns the shared http client of the current context.
+func Client() (*http.Client, error) {
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	if client, ok := clients[envCfg.Context]; ok {
+		return client, nil
+	}
+
+	tlsConfig, err := newTLSConfig()
+	if err != nil {
+		return nil, err
+	}
+	base, err := newTransport()
+	if err != nil {
+		return nil, err
+	}
+	base.TLSClientConfig = tlsConfig
+
+	// first registered middleware is the outermost
+	var roundTripper http.RoundTripper = base
+	for i := len(middlewares) - 1; i >= 0; i-- {
+		roundTripper = middlewares[i](roundTripper)
+	}
+
+	client := &http.Client{
+		Transport: roundTripper,
+		Timeout:   durationOr(envCfg.HTTP.Timeout, defaultTimeout),
+	}
+	clients[envCfg.Context] = client
+	return client, nil
+}
+
+// Use registers middleware on shared clients. Clients created earlier are
+// dropped from cache so that every later request goes through it.
+func Use(middleware Middleware) {
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	middlewares = append(middlewares, middleware)
+	clients = make(map[string]*http.Client)
+}
+
+// newTransport creates transport with connection and proxy settings of the context.
+func newTransport() (*http.Transport, error) {
+	settings := envCfg.HTTP
+	base := http.DefaultTransport.(*http.Transport).Clone()
+
+	// timeouts
+	dialer := &net.Dialer{
+		Timeout:   durationOr(settings.DialTimeout, defaultDialTimeout),
+		KeepAlive: 30 * time.Second,
+	}
+	base.DialContext = dialer.DialContext
+	base.TLSHandshakeTimeout = durationOr(settings.TLSHandshakeTimeout, defaultTLSHandshakeTimeout)
+	base.ResponseHeaderTimeout = durationOr(settings.ResponseHeaderTimeout, defaultResponseHeaderTimeout)
+
+	// connection pool sized for bulk operations
+	base.MaxIdleConnsPerHost = defaultMaxIdleConnsPerHost
+	if settings.MaxIdleConnsPerHost > 0 {
+		base.MaxIdleConnsPerHost = settings.MaxIdleConnsPerHost
+	}
+	if base.MaxIdleConns < base.MaxIdleConnsPerHost {
+		base.MaxIdleConns = base.MaxIdleConnsPerHost
+	}
+
+	// http/2
+	if settings.DisableHTTP2 {
+		base.ForceAttemptHTTP2 = false
+		base.TLSNextProto = make(map[string]func(string, *tls.Conn) http.RoundTripper)
+	}
+
+	// unix socket sidecars
+	if settings.UnixSocket != "" {
+		base.DialContext = func(ctx context.Context, _, _ string) (net.Conn, error) {
+			return dialer.DialContext(ctx, "unix", settings.UnixSocket)
+		}
+	}
+
+	// proxy
+	proxy := http.ProxyFromEnvironment
+	if settings.Proxy != "" {
+		proxyURL, err := url.Parse(settings.Proxy)
+		if err != nil {
+			return nil, fmt.Errorf("Invalid proxy URL %q: %v", settings.Proxy, err)
+		}
+		proxy = http.ProxyURL(proxyURL)
+	}
+	noProxy := splitNoProxy(settings.NoProxy)
+	base.Proxy = func(req *http.Request) (*url.URL, error) {
+		if bypassProxy(req.URL.Hostname(), noProxy) {
+			return nil, nil
+		}
+		return proxy(req)
+	}
+
+	return base, nil
+}
+
+// durationOr returns value, or def if value is not set.
+func durationOr(value time.Duration, def time.Duration) time.Duration {
+	if value == 0 {
+		return def
+	}
+	return value
+}
+
+// splitNoProxy parses comma-separated NO_PROXY list.
+func splitNoProxy(list string) []string {
+	var entries []string
+	for _, entry := range strings.Split(list, ",") {
+		if entry = strings.ToLower(strings.TrimSpace(entry)); entry != "" {
+			entries = append(entries, entry)
+		}
+	}
+	return entries
+}
+
+// bypassProxy checks if host matches NO_PROXY entries. Entries can be "*",
+// hosts, IPs, CIDR ranges or domains which also match their subdomains.
+func bypassProxy(host string, noProxy []string) bool {
+	host = strings.ToLower(host)
+	ip := net.ParseIP(host)
+	for _, entry := range noProxy {
+		if entry == "*" || entry == host {
+			return true
+		}
+		if _, cidr, err := net.ParseCIDR(entry); err == nil {
+			if ip != nil && cidr.Contains(ip) {
+				return true
+			}
+			continue
+		}
+		if strings.HasSuffix(host, "."+strings.TrimPrefix(entry, ".")) {
+			return true
+		}
+	}
+	return false
+}
+
+// newTLSConfig creates TLS config from context settings.
+func newTLSConfig() (*tls.Config, error) {
+	settings := envCfg.TLS
+	tlsConfig := &tls.Config{
+		ServerName: settings.ServerName,
+	}
+
+	// minimum version
+	if settings.MinVersion != "" {
+		version, ok := tlsVersions[settings.MinVersion]
+		if !ok {
+			return nil, fmt.Errorf("Unsupported TLS version %q, use one of 1.0, 1.1, 1.2 or 1.3.", settings.MinVersion)
+		}
+		tlsConfig.MinVersion = version
+	}
+
+	// custom CA bundle
+	if settings.CAFile != "" {
+		pem, err := ioutil.ReadFile(settings.CAFile)
+		if err != nil {
+			return nil, err
+		}
+		pool, err := x509.SystemCertPool()
+		if err != nil || pool == nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("No certificates found in CA file %s.", settings.CAFile)
+		}
+		tlsConfig.RootCAs = pool
+	}
+
+	// client certificate
+	if settings.CertFile != "" || settings.KeyFile != "" {
+		if settings.CertFile == "" || settings.KeyFile == "" {
+			return nil, fmt.Errorf("Both tls.cert_file and tls.key_file are required for client certificates.")
+		}
+		cert, err := tls.LoadX509KeyPair(settings.CertFile, settings.KeyFile)
+		if err != nil {
+			return nil, err
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+
+	// insecure
+	if settings.InsecureSkipVerify {
+		CLI.Warn("TLS certificate verification is DISABLED for context %q. Connections can be intercepted!", PrintNonZero(envCfg.Context))
+		tlsConfig.InsecureSkipVerify = true
+	}
+
+	return tlsConfig, nil
+}
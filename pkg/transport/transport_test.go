@@ -0,0 +1,33 @@
+package transport
+
+import "testing"
+
+func TestBypassProxy(t *testing.T) {
+	noProxy := splitNoProxy("localhost, .internal.example.com,example.org,10.0.0.0/8")
+	tests := []struct {
+		host string
+		want bool
+	}{
+		{"localhost", true},
+		{"LOCALHOST", true},
+		{"api.internal.example.com", true},
+		{"internal.example.com", false},
+		{"example.org", true},
+		{"www.example.org", true},
+		{"notexample.org", false},
+		{"10.1.2.3", true},
+		{"11.1.2.3", false},
+		{"example.com", false},
+	}
+	for _, test := range tests {
+		t.Run(test.host, func(t *testing.T) {
+			if got := bypassProxy(test.host, noProxy); got != test.want {
+				t.Errorf("bypassProxy(%q) = %v, want %v", test.host, got, test.want)
+			}
+		})
+	}
+
+	if !bypassProxy("anything", []string{"*"}) {
+		t.Error("bypassProxy with * must match every host")
+	}
+}